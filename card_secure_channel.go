@@ -0,0 +1,130 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/sha512"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/apdu"
+	kcrypto "github.com/status-im/keycard-go/crypto"
+)
+
+// cardSecureChannel is the card side of the Keycard secure channel. It is used
+// wherever this package has to stand in for a card: the emulator, the session
+// recorder and the replay transport.
+type cardSecureChannel struct {
+	opened        bool
+	authenticated bool
+	encKey        []byte
+	macKey        []byte
+	iv            []byte
+}
+
+// open derives the session keys from the OPEN SECURE CHANNEL exchange, where
+// resp is the salt followed by the initial IV sent back to the client.
+func (sc *cardSecureChannel) open(scKey *ecdsa.PrivateKey, clientKey *ecdsa.PublicKey, pairingKey []byte, resp []byte) {
+	h := sha512.New()
+	h.Write(kcrypto.GenerateECDHSharedSecret(scKey, clientKey))
+	h.Write(pairingKey)
+	h.Write(resp[:32])
+	keys := h.Sum(nil)
+
+	*sc = cardSecureChannel{
+		opened: true,
+		encKey: keys[:32],
+		macKey: keys[32:],
+		iv:     append([]byte{}, resp[32:]...),
+	}
+}
+
+func (sc *cardSecureChannel) decrypt(cmd *apdu.Command) ([]byte, bool) {
+	if len(cmd.Data) < 2*aes.BlockSize || len(cmd.Data)%aes.BlockSize != 0 {
+		return nil, false
+	}
+
+	mac := cmd.Data[:aes.BlockSize]
+	encData := cmd.Data[aes.BlockSize:]
+	meta := []byte{cmd.Cla, cmd.Ins, cmd.P1, cmd.P2, byte(len(cmd.Data)), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	expected, err := kcrypto.CalculateMac(meta, encData, sc.macKey)
+
+	if err != nil || !bytes.Equal(expected, mac) {
+		return nil, false
+	}
+
+	plain, err := kcrypto.DecryptData(encData, sc.encKey, sc.iv)
+
+	if err != nil {
+		return nil, false
+	}
+
+	sc.iv = append([]byte{}, mac...)
+
+	return plain, true
+}
+
+func (sc *cardSecureChannel) encrypt(plain []byte) ([]byte, error) {
+	encData, err := kcrypto.EncryptData(plain, sc.encKey, sc.iv)
+
+	if err != nil {
+		return nil, err
+	}
+
+	meta := []byte{byte(len(encData) + aes.BlockSize), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	mac, err := kcrypto.CalculateMac(meta, encData, sc.macKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sc.iv = append([]byte{}, mac...)
+
+	return append(append([]byte{}, mac...), encData...), nil
+}
+
+// oneShotDecrypt decrypts the payload of an INIT command.
+func oneShotDecrypt(scKey *ecdsa.PrivateKey, data []byte) ([]byte, bool) {
+	if len(data) < 1 || len(data) < 1+int(data[0])+aes.BlockSize {
+		return nil, false
+	}
+
+	pubKeyLen := int(data[0])
+	pubKey, err := crypto.UnmarshalPubkey(data[1 : 1+pubKeyLen])
+
+	if err != nil {
+		return nil, false
+	}
+
+	iv := data[1+pubKeyLen : 1+pubKeyLen+aes.BlockSize]
+	ciphertext := data[1+pubKeyLen+aes.BlockSize:]
+
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, false
+	}
+
+	block, err := aes.NewCipher(kcrypto.GenerateECDHSharedSecret(scKey, pubKey))
+
+	if err != nil {
+		return nil, false
+	}
+
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, ciphertext)
+
+	return removeISOPadding(plain), true
+}
+
+func removeISOPadding(data []byte) []byte {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-aes.BlockSize; i-- {
+		if data[i] == 0x80 {
+			return data[:i]
+		} else if data[i] != 0x00 {
+			break
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,819 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	keycard "github.com/status-im/keycard-go"
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/globalplatform"
+	"github.com/status-im/keycard-go/identifiers"
+	ktypes "github.com/status-im/keycard-go/types"
+)
+
+const (
+	swWrongLength           = 0x6700
+	swConditionsNotSatisfed = 0x6985
+	swWrongData             = 0x6A80
+	swIncorrectP1P2         = 0x6A86
+	swInsNotSupported       = 0x6D00
+	swWrongCredentials      = 0x63C0
+)
+
+var emulatedVersion = []byte{0x03, 0x01}
+
+// EmulatedCard is an in-memory implementation of the Keycard applet. It speaks
+// the same APDU protocol as a real card, including pairing and the secure
+// channel, so the regular KeycardFlow can be run against it through an
+// Emulator.
+type EmulatedCard struct {
+	mu sync.Mutex
+
+	instanceUID   []byte
+	scKey         *ecdsa.PrivateKey
+	initialized   bool
+	pin           string
+	puk           string
+	pinRetries    int
+	pukRetries    int
+	pairingSecret []byte
+	pairings      [maxFreeSlots][]byte
+	master        *emulatedKey
+	keyUID        []byte
+	currentPath   []uint32
+	data          map[uint8][]byte
+
+	// session state, cleared on SELECT and on every new connection
+	selected      bool
+	cardChallenge []byte
+	sc            cardSecureChannel
+	pinVerified   bool
+}
+
+// NewEmulatedCard returns a factory fresh card: the applet is installed but not
+// initialized.
+func NewEmulatedCard() (*EmulatedCard, error) {
+	c := &EmulatedCard{}
+
+	if err := c.reset(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Initialize sets the card credentials, the same way an INIT command would.
+func (c *EmulatedCard) Initialize(pin, puk, pairingPass string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.initialized {
+		return errors.New("already initialized")
+	}
+
+	c.initCredentials(pin, puk, keycard.NewSecrets(pin, puk, pairingPass).PairingToken())
+
+	return nil
+}
+
+// LoadMnemonic loads the key derived from the given BIP39 mnemonic.
+func (c *EmulatedCard) LoadMnemonic(mnemonic string, password string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.loadSeed(mnemonicToSeed(mnemonic, password))
+}
+
+// InstanceUID returns the hex encoded instance UID of the card.
+func (c *EmulatedCard) InstanceUID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return btox(c.instanceUID)
+}
+
+// KeyUID returns the hex encoded key UID of the card, empty if no key is loaded.
+func (c *EmulatedCard) KeyUID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return btox(c.keyUID)
+}
+
+// Transmit processes a raw command APDU and returns the raw response APDU.
+func (c *EmulatedCard) Transmit(raw []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cmd, err := apdu.ParseCommand(raw)
+
+	if err != nil {
+		return swBytes(nil, swWrongLength), nil
+	}
+
+	data, sw := c.process(cmd)
+	return swBytes(data, sw), nil
+}
+
+func (c *EmulatedCard) reset() error {
+	instanceUID := make([]byte, 16)
+
+	if _, err := rand.Read(instanceUID); err != nil {
+		return err
+	}
+
+	scKey, err := crypto.GenerateKey()
+
+	if err != nil {
+		return err
+	}
+
+	c.instanceUID = instanceUID
+	c.scKey = scKey
+	c.initialized = false
+	c.pin = ""
+	c.puk = ""
+	c.pinRetries = 0
+	c.pukRetries = 0
+	c.pairingSecret = nil
+	c.pairings = [maxFreeSlots][]byte{}
+	c.master = nil
+	c.keyUID = nil
+	c.currentPath = nil
+	c.data = map[uint8][]byte{}
+	c.clearSession()
+
+	return nil
+}
+
+func (c *EmulatedCard) resetSession() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clearSession()
+}
+
+func (c *EmulatedCard) clearSession() {
+	c.selected = false
+	c.cardChallenge = nil
+	c.sc = cardSecureChannel{}
+	c.pinVerified = false
+}
+
+func (c *EmulatedCard) initCredentials(pin string, puk string, pairingSecret []byte) {
+	c.initialized = true
+	c.pin = pin
+	c.puk = puk
+	c.pinRetries = maxPINRetries
+	c.pukRetries = maxPUKRetries
+	c.pairingSecret = pairingSecret
+}
+
+func (c *EmulatedCard) loadSeed(seed []byte) error {
+	master, err := newMasterKey(seed)
+
+	if err != nil {
+		return err
+	}
+
+	priv, err := master.privateKey()
+
+	if err != nil {
+		return err
+	}
+
+	keyUID := sha256.Sum256(crypto.FromECDSAPub(&priv.PublicKey))
+
+	c.master = master
+	c.keyUID = keyUID[:]
+	c.currentPath = nil
+
+	return nil
+}
+
+func (c *EmulatedCard) freeSlots() int {
+	free := 0
+
+	for _, p := range c.pairings {
+		if p == nil {
+			free++
+		}
+	}
+
+	return free
+}
+
+func (c *EmulatedCard) process(cmd *apdu.Command) ([]byte, uint16) {
+	if cmd.Cla == globalplatform.ClaISO7816 && cmd.Ins == globalplatform.InsSelect {
+		return c.selectApplet(cmd)
+	}
+
+	if !c.selected {
+		return nil, swInsNotSupported
+	}
+
+	switch cmd.Ins {
+	case keycard.InsInit:
+		return c.initApplet(cmd)
+	case keycard.InsFactoryReset:
+		return c.factoryReset(cmd)
+	case keycard.InsPair:
+		return c.pair(cmd)
+	case keycard.InsOpenSecureChannel:
+		return c.openSecureChannel(cmd)
+	}
+
+	if !c.sc.opened {
+		if cmd.Ins == keycard.InsGetData {
+			return c.getData(cmd)
+		}
+
+		return nil, globalplatform.SwSecurityConditionNotSatisfied
+	}
+
+	plain, ok := c.sc.decrypt(cmd)
+
+	if !ok {
+		c.sc = cardSecureChannel{}
+		return nil, globalplatform.SwSecurityConditionNotSatisfied
+	}
+
+	cmd.Data = plain
+	data, sw := c.processSecure(cmd)
+
+	enc, err := c.sc.encrypt(swBytes(data, sw))
+
+	if err != nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	return enc, apdu.SwOK
+}
+
+func (c *EmulatedCard) processSecure(cmd *apdu.Command) ([]byte, uint16) {
+	if cmd.Ins == keycard.InsMutuallyAuthenticate {
+		return c.mutuallyAuthenticate()
+	}
+
+	if !c.sc.authenticated {
+		return nil, swConditionsNotSatisfed
+	}
+
+	switch cmd.Ins {
+	case keycard.InsGetStatus:
+		return c.getStatus(cmd)
+	case keycard.InsVerifyPIN:
+		return c.verifyPIN(cmd)
+	case keycard.InsChangePIN:
+		return c.changePIN(cmd)
+	case keycard.InsUnblockPIN:
+		return c.unblockPIN(cmd)
+	case keycard.InsUnpair:
+		return c.unpair(cmd)
+	case keycard.InsLoadKey:
+		return c.loadKey(cmd)
+	case keycard.InsGenerateKey:
+		return c.generateKey()
+	case keycard.InsGenerateMnemonic:
+		return c.generateMnemonic(cmd)
+	case keycard.InsRemoveKey:
+		return c.removeKey()
+	case keycard.InsDeriveKey:
+		return c.deriveKey(cmd)
+	case keycard.InsExportKey:
+		return c.exportKey(cmd)
+	case keycard.InsSign:
+		return c.sign(cmd)
+	case keycard.InsStoreData:
+		return c.storeData(cmd)
+	case keycard.InsGetData:
+		return c.getData(cmd)
+	default:
+		return nil, swInsNotSupported
+	}
+}
+
+func (c *EmulatedCard) selectApplet(cmd *apdu.Command) ([]byte, uint16) {
+	c.clearSession()
+
+	aid, err := identifiers.KeycardInstanceAID(identifiers.KeycardDefaultInstanceIndex)
+
+	if err != nil || !bytes.Equal(cmd.Data, aid) {
+		return nil, globalplatform.SwFileNotFound
+	}
+
+	c.selected = true
+	pubKey := crypto.FromECDSAPub(&c.scKey.PublicKey)
+
+	if !c.initialized {
+		return tlv(ktypes.TagSelectResponsePreInitialized, pubKey), apdu.SwOK
+	}
+
+	info := new(bytes.Buffer)
+	info.Write(tlv(0x8F, c.instanceUID))
+	info.Write(tlv(0x80, pubKey))
+	info.Write(tlv(0x02, emulatedVersion))
+	info.Write(tlv(0x02, []byte{byte(c.freeSlots())}))
+	info.Write(tlv(0x8E, c.keyUID))
+	info.Write(tlv(ktypes.TagApplicationInfoCapabilities, []byte{byte(ktypes.CapabilityAll)}))
+
+	return tlv(ktypes.TagApplicationInfoTemplate, info.Bytes()), apdu.SwOK
+}
+
+func (c *EmulatedCard) initApplet(cmd *apdu.Command) ([]byte, uint16) {
+	if c.initialized {
+		return nil, swInsNotSupported
+	}
+
+	plain, ok := oneShotDecrypt(c.scKey, cmd.Data)
+
+	if !ok || len(plain) != defPINLen+defPUKLen+32 {
+		return nil, swWrongData
+	}
+
+	c.initCredentials(string(plain[:defPINLen]), string(plain[defPINLen:defPINLen+defPUKLen]), plain[defPINLen+defPUKLen:])
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) factoryReset(cmd *apdu.Command) ([]byte, uint16) {
+	if cmd.P1 != keycard.P1FactoryResetMagic || cmd.P2 != keycard.P2FactoryResetMagic {
+		return nil, swIncorrectP1P2
+	}
+
+	if err := c.reset(); err != nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) pair(cmd *apdu.Command) ([]byte, uint16) {
+	if !c.initialized {
+		return nil, swConditionsNotSatisfed
+	}
+
+	if len(cmd.Data) != 32 {
+		return nil, swWrongData
+	}
+
+	switch cmd.P1 {
+	case keycard.P1PairingFirstStep:
+		if c.freeSlots() == 0 {
+			return nil, keycard.SwNoAvailablePairingSlots
+		}
+
+		c.cardChallenge = make([]byte, 32)
+
+		if _, err := rand.Read(c.cardChallenge); err != nil {
+			return nil, swConditionsNotSatisfed
+		}
+
+		cryptogram := sha256.Sum256(append(append([]byte{}, c.pairingSecret...), cmd.Data...))
+
+		return append(cryptogram[:], c.cardChallenge...), apdu.SwOK
+	case keycard.P1PairingFinalStep:
+		if c.cardChallenge == nil {
+			return nil, swConditionsNotSatisfed
+		}
+
+		expected := sha256.Sum256(append(append([]byte{}, c.pairingSecret...), c.cardChallenge...))
+		c.cardChallenge = nil
+
+		if !bytes.Equal(expected[:], cmd.Data) {
+			return nil, globalplatform.SwSecurityConditionNotSatisfied
+		}
+
+		for i := range c.pairings {
+			if c.pairings[i] != nil {
+				continue
+			}
+
+			salt := make([]byte, 32)
+
+			if _, err := rand.Read(salt); err != nil {
+				return nil, swConditionsNotSatisfed
+			}
+
+			key := sha256.Sum256(append(append([]byte{}, c.pairingSecret...), salt...))
+			c.pairings[i] = key[:]
+
+			return append([]byte{byte(i)}, salt...), apdu.SwOK
+		}
+
+		return nil, keycard.SwNoAvailablePairingSlots
+	default:
+		return nil, swIncorrectP1P2
+	}
+}
+
+func (c *EmulatedCard) openSecureChannel(cmd *apdu.Command) ([]byte, uint16) {
+	c.sc = cardSecureChannel{}
+	c.pinVerified = false
+
+	if !c.initialized {
+		return nil, swConditionsNotSatisfed
+	}
+
+	if int(cmd.P1) >= len(c.pairings) || c.pairings[cmd.P1] == nil {
+		return nil, swIncorrectP1P2
+	}
+
+	pubKey, err := crypto.UnmarshalPubkey(cmd.Data)
+
+	if err != nil {
+		return nil, swWrongData
+	}
+
+	resp := make([]byte, 32+aes.BlockSize)
+
+	if _, err := rand.Read(resp); err != nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	c.sc.open(c.scKey, pubKey, c.pairings[cmd.P1], resp)
+
+	return resp, apdu.SwOK
+}
+
+func (c *EmulatedCard) mutuallyAuthenticate() ([]byte, uint16) {
+	if c.sc.authenticated {
+		return nil, swConditionsNotSatisfed
+	}
+
+	challenge := make([]byte, 32)
+
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	c.sc.authenticated = true
+
+	return challenge, apdu.SwOK
+}
+
+func (c *EmulatedCard) getStatus(cmd *apdu.Command) ([]byte, uint16) {
+	switch cmd.P1 {
+	case keycard.P1GetStatusApplication:
+		keyInitialized := byte(0x00)
+
+		if c.master != nil {
+			keyInitialized = 0xFF
+		}
+
+		status := new(bytes.Buffer)
+		status.Write(tlv(0x02, []byte{byte(c.pinRetries)}))
+		status.Write(tlv(0x02, []byte{byte(c.pukRetries)}))
+		status.Write(tlv(0x01, []byte{keyInitialized}))
+
+		return tlv(ktypes.TagApplicationStatusTemplate, status.Bytes()), apdu.SwOK
+	case keycard.P1GetStatusKeyPath:
+		return encodePath(c.currentPath), apdu.SwOK
+	default:
+		return nil, swIncorrectP1P2
+	}
+}
+
+func (c *EmulatedCard) verifyPIN(cmd *apdu.Command) ([]byte, uint16) {
+	if c.pinRetries == 0 {
+		return nil, swWrongCredentials
+	}
+
+	if string(cmd.Data) != c.pin {
+		c.pinVerified = false
+		c.pinRetries--
+		return nil, swWrongCredentials | uint16(c.pinRetries)
+	}
+
+	c.pinVerified = true
+	c.pinRetries = maxPINRetries
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) changePIN(cmd *apdu.Command) ([]byte, uint16) {
+	if !c.pinVerified {
+		return nil, swConditionsNotSatisfed
+	}
+
+	switch cmd.P1 {
+	case keycard.P1ChangePinPIN:
+		if len(cmd.Data) != defPINLen {
+			return nil, swWrongData
+		}
+
+		c.pin = string(cmd.Data)
+	case keycard.P1ChangePinPUK:
+		if len(cmd.Data) != defPUKLen {
+			return nil, swWrongData
+		}
+
+		c.puk = string(cmd.Data)
+	case keycard.P1ChangePinPairingSecret:
+		if len(cmd.Data) != 32 {
+			return nil, swWrongData
+		}
+
+		c.pairingSecret = append([]byte{}, cmd.Data...)
+	default:
+		return nil, swIncorrectP1P2
+	}
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) unblockPIN(cmd *apdu.Command) ([]byte, uint16) {
+	if c.pinRetries != 0 {
+		return nil, swConditionsNotSatisfed
+	}
+
+	if c.pukRetries == 0 {
+		return nil, swWrongCredentials
+	}
+
+	if len(cmd.Data) != defPUKLen+defPINLen {
+		return nil, swWrongData
+	}
+
+	if string(cmd.Data[:defPUKLen]) != c.puk {
+		c.pukRetries--
+		return nil, swWrongCredentials | uint16(c.pukRetries)
+	}
+
+	c.pin = string(cmd.Data[defPUKLen:])
+	c.pinRetries = maxPINRetries
+	c.pukRetries = maxPUKRetries
+	c.pinVerified = true
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) unpair(cmd *apdu.Command) ([]byte, uint16) {
+	if !c.pinVerified {
+		return nil, swConditionsNotSatisfed
+	}
+
+	if int(cmd.P1) >= len(c.pairings) {
+		return nil, swIncorrectP1P2
+	}
+
+	c.pairings[cmd.P1] = nil
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) loadKey(cmd *apdu.Command) ([]byte, uint16) {
+	if !c.pinVerified {
+		return nil, swConditionsNotSatisfed
+	}
+
+	if cmd.P1 != keycard.P1LoadKeySeed {
+		return nil, swIncorrectP1P2
+	}
+
+	if len(cmd.Data) < 16 || len(cmd.Data) > 64 {
+		return nil, swWrongData
+	}
+
+	if err := c.loadSeed(cmd.Data); err != nil {
+		return nil, swWrongData
+	}
+
+	return c.keyUID, apdu.SwOK
+}
+
+func (c *EmulatedCard) generateKey() ([]byte, uint16) {
+	if !c.pinVerified {
+		return nil, swConditionsNotSatisfed
+	}
+
+	seed := make([]byte, 64)
+
+	if _, err := rand.Read(seed); err != nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	if err := c.loadSeed(seed); err != nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	return c.keyUID, apdu.SwOK
+}
+
+func (c *EmulatedCard) generateMnemonic(cmd *apdu.Command) ([]byte, uint16) {
+	if cmd.P1 < 4 || cmd.P1 > 8 {
+		return nil, swIncorrectP1P2
+	}
+
+	indexes, err := generateMnemonicIndexes(int(cmd.P1))
+
+	if err != nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	resp := make([]byte, 2*len(indexes))
+
+	for i, idx := range indexes {
+		binary.BigEndian.PutUint16(resp[2*i:], idx)
+	}
+
+	return resp, apdu.SwOK
+}
+
+func (c *EmulatedCard) removeKey() ([]byte, uint16) {
+	if !c.pinVerified {
+		return nil, swConditionsNotSatisfed
+	}
+
+	c.master = nil
+	c.keyUID = nil
+	c.currentPath = nil
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) resolvePath(p1 uint8, data []byte) ([]uint32, bool) {
+	path, ok := decodePath(data)
+
+	if !ok {
+		return nil, false
+	}
+
+	switch p1 & 0xC0 {
+	case keycard.P1DeriveKeyFromMaster:
+		return path, true
+	case keycard.P1DeriveKeyFromParent:
+		if len(c.currentPath) == 0 {
+			return nil, false
+		}
+
+		return append(append([]uint32{}, c.currentPath[:len(c.currentPath)-1]...), path...), true
+	case keycard.P1DeriveKeyFromCurrent:
+		return append(append([]uint32{}, c.currentPath...), path...), true
+	default:
+		return nil, false
+	}
+}
+
+func (c *EmulatedCard) deriveKey(cmd *apdu.Command) ([]byte, uint16) {
+	if !c.pinVerified || c.master == nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	path, ok := c.resolvePath(cmd.P1, cmd.Data)
+
+	if !ok {
+		return nil, swWrongData
+	}
+
+	c.currentPath = path
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) exportKey(cmd *apdu.Command) ([]byte, uint16) {
+	if !c.pinVerified || c.master == nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	path := c.currentPath
+
+	if cmd.P1&0x0F != keycard.P1ExportKeyCurrent {
+		var ok bool
+		path, ok = c.resolvePath(cmd.P1, cmd.Data)
+
+		if !ok {
+			return nil, swWrongData
+		}
+	}
+
+	key, err := c.master.derive(path)
+
+	if err != nil {
+		return nil, swWrongData
+	}
+
+	priv, err := key.privateKey()
+
+	if err != nil {
+		return nil, swWrongData
+	}
+
+	tpl := new(bytes.Buffer)
+	tpl.Write(tlv(0x80, crypto.FromECDSAPub(&priv.PublicKey)))
+
+	switch cmd.P2 {
+	case keycard.P2ExportKeyPrivateAndPublic:
+		if !isEIP1581Path(path) {
+			return nil, swIncorrectP1P2
+		}
+
+		tpl.Write(tlv(0x81, key.key))
+	case keycard.P2ExportKeyPublicOnly:
+	case keycard.P2ExportKeyExtendedPublic:
+		tpl.Write(tlv(0x82, key.chainCode))
+	default:
+		return nil, swIncorrectP1P2
+	}
+
+	if cmd.P1&0x0F == keycard.P1ExportKeyDeriveAndMakeCurrent {
+		c.currentPath = path
+	}
+
+	return tlv(ktypes.TagExportKeyTemplate, tpl.Bytes()), apdu.SwOK
+}
+
+func (c *EmulatedCard) sign(cmd *apdu.Command) ([]byte, uint16) {
+	if !c.pinVerified || c.master == nil {
+		return nil, swConditionsNotSatisfed
+	}
+
+	if cmd.P2 != 0x01 {
+		return nil, swIncorrectP1P2
+	}
+
+	if len(cmd.Data) < 32 {
+		return nil, swWrongData
+	}
+
+	path := c.currentPath
+
+	switch cmd.P1 {
+	case keycard.P1SignCurrentKey:
+	case keycard.P1SignDerive, keycard.P1SignDeriveAndMakeCurrent:
+		var ok bool
+		path, ok = decodePath(cmd.Data[32:])
+
+		if !ok {
+			return nil, swWrongData
+		}
+	default:
+		return nil, globalplatform.SwReferencedDataNotFound
+	}
+
+	key, err := c.master.derive(path)
+
+	if err != nil {
+		return nil, swWrongData
+	}
+
+	priv, err := key.privateKey()
+
+	if err != nil {
+		return nil, swWrongData
+	}
+
+	sig, err := crypto.Sign(cmd.Data[:32], priv)
+
+	if err != nil {
+		return nil, swWrongData
+	}
+
+	if cmd.P1 == keycard.P1SignDeriveAndMakeCurrent {
+		c.currentPath = path
+	}
+
+	return tlv(ktypes.TagRawSignature, sig), apdu.SwOK
+}
+
+func (c *EmulatedCard) storeData(cmd *apdu.Command) ([]byte, uint16) {
+	if !c.pinVerified {
+		return nil, swConditionsNotSatisfed
+	}
+
+	if cmd.P1 > keycard.P1StoreDataCash {
+		return nil, swIncorrectP1P2
+	}
+
+	c.data[cmd.P1] = append([]byte{}, cmd.Data...)
+
+	return nil, apdu.SwOK
+}
+
+func (c *EmulatedCard) getData(cmd *apdu.Command) ([]byte, uint16) {
+	if cmd.P1 > keycard.P1StoreDataCash {
+		return nil, swIncorrectP1P2
+	}
+
+	return c.data[cmd.P1], apdu.SwOK
+}
+
+func swBytes(data []byte, sw uint16) []byte {
+	return append(append([]byte{}, data...), byte(sw>>8), byte(sw))
+}
+
+func tlv(tag uint8, value []byte) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(tag)
+	apdu.WriteLength(buf, uint32(len(value)))
+	buf.Write(value)
+
+	return buf.Bytes()
+}
@@ -0,0 +1,340 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	keycard "github.com/status-im/keycard-go"
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/globalplatform"
+	"github.com/status-im/keycard-go/io"
+)
+
+const (
+	testPIN      = "123456"
+	testPUK      = "123456789012"
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	// testAddress is the address of testMnemonic at m/44'/60'/0'/0/0
+	testAddress = "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+)
+
+// newTestCard returns an emulated card initialized with the test credentials.
+func newTestCard(t *testing.T) *EmulatedCard {
+	t.Helper()
+
+	card, err := NewEmulatedCard()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := card.Initialize(testPIN, testPUK, DefPairing); err != nil {
+		t.Fatal(err)
+	}
+
+	return card
+}
+
+// openTestCard selects the applet, pairs and opens a secure channel with the
+// card.
+func openTestCard(t *testing.T, card *EmulatedCard) *keycard.CommandSet {
+	t.Helper()
+
+	cmdSet := keycard.NewCommandSet(io.NewNormalChannel(card))
+
+	if err := cmdSet.Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdSet.Pair(DefPairing); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdSet.OpenSecureChannel(); err != nil {
+		t.Fatal(err)
+	}
+
+	return cmdSet
+}
+
+func transmitSW(t *testing.T, card *EmulatedCard, cmd *apdu.Command) uint16 {
+	t.Helper()
+
+	raw, err := cmd.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := card.Transmit(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := apdu.ParseResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r.Sw
+}
+
+func TestEmulatedCardRequiresSelect(t *testing.T) {
+	card := newTestCard(t)
+
+	if sw := transmitSW(t, card, keycard.NewCommandPairFirstStep(make([]byte, 32))); sw != swInsNotSupported {
+		t.Fatalf("unexpected sw %x", sw)
+	}
+
+	if sw := transmitSW(t, card, apdu.NewCommand(globalplatform.ClaISO7816, globalplatform.InsSelect, 0x04, 0, []byte{1, 2, 3})); sw != globalplatform.SwFileNotFound {
+		t.Fatalf("unexpected sw %x", sw)
+	}
+}
+
+func TestEmulatedCardInit(t *testing.T) {
+	card, err := NewEmulatedCard()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmdSet := keycard.NewCommandSet(io.NewNormalChannel(card))
+
+	if err := cmdSet.Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cmdSet.ApplicationInfo.Installed || cmdSet.ApplicationInfo.Initialized {
+		t.Fatalf("unexpected info %+v", cmdSet.ApplicationInfo)
+	}
+
+	if err := cmdSet.Init(keycard.NewSecrets(testPIN, testPUK, DefPairing)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdSet.Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	info := cmdSet.ApplicationInfo
+
+	if !info.Initialized || btox(info.InstanceUID) != card.InstanceUID() || len(info.KeyUID) != 0 {
+		t.Fatalf("unexpected info %+v", info)
+	}
+
+	if err := cmdSet.Init(keycard.NewSecrets(testPIN, testPUK, DefPairing)); err == nil {
+		t.Fatal("initialized twice")
+	}
+}
+
+func TestEmulatedCardSecureChannel(t *testing.T) {
+	card := newTestCard(t)
+	cmdSet := keycard.NewCommandSet(io.NewNormalChannel(card))
+
+	if err := cmdSet.Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdSet.Pair("wrong"); err == nil {
+		t.Fatal("paired with the wrong password")
+	}
+
+	if sw := transmitSW(t, card, keycard.NewCommandGetStatus(keycard.P1GetStatusApplication)); sw != globalplatform.SwSecurityConditionNotSatisfied {
+		t.Fatalf("unexpected sw %x", sw)
+	}
+
+	cmdSet = openTestCard(t, card)
+
+	status, err := cmdSet.GetStatusApplication()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.PinRetryCount != maxPINRetries || status.PUKRetryCount != maxPUKRetries || status.KeyInitialized {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	// a new SELECT ends the secure channel
+	if err := cmdSet.Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cmdSet.GetStatusApplication(); err == nil {
+		t.Fatal("secure channel survived SELECT")
+	}
+}
+
+func TestEmulatedCardPINAndPUK(t *testing.T) {
+	card := newTestCard(t)
+	cmdSet := openTestCard(t, card)
+
+	if err := cmdSet.ChangePIN("654321"); err == nil {
+		t.Fatal("changed the PIN without verifying it")
+	}
+
+	for i := maxPINRetries - 1; i >= 0; i-- {
+		err := cmdSet.VerifyPIN("000000")
+
+		if e, ok := err.(*keycard.WrongPINError); !ok || e.RemainingAttempts != i {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	if err := cmdSet.VerifyPIN(testPIN); err == nil {
+		t.Fatal("verified a blocked PIN")
+	}
+
+	err := cmdSet.UnblockPIN("000000000000", "654321")
+
+	if e, ok := err.(*keycard.WrongPUKError); !ok || e.RemainingAttempts != maxPUKRetries-1 {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := cmdSet.UnblockPIN(testPUK, "654321"); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := cmdSet.GetStatusApplication()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.PinRetryCount != maxPINRetries || status.PUKRetryCount != maxPUKRetries {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	if err := cmdSet.ChangePIN(testPIN); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdSet.VerifyPIN(testPIN); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmulatedCardKeys(t *testing.T) {
+	card := newTestCard(t)
+	cmdSet := openTestCard(t, card)
+
+	if _, err := cmdSet.SignWithPath(make([]byte, 32), "m/44'/60'/0'/0/0"); err == nil {
+		t.Fatal("signed without PIN")
+	}
+
+	if err := cmdSet.VerifyPIN(testPIN); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cmdSet.SignWithPath(make([]byte, 32), "m/44'/60'/0'/0/0"); err == nil {
+		t.Fatal("signed without key")
+	}
+
+	keyUID, err := cmdSet.LoadSeed(mnemonicToSeed(testMnemonic, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if btox(keyUID) != card.KeyUID() {
+		t.Fatalf("key UID %x, card reports %s", keyUID, card.KeyUID())
+	}
+
+	_, pubKey, err := cmdSet.ExportKey(true, false, true, "m/44'/60'/0'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := crypto.UnmarshalPubkey(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address := crypto.PubkeyToAddress(*key).Hex(); address != testAddress {
+		t.Fatalf("unexpected address %s", address)
+	}
+
+	// only EIP-1581 keys can be exported with their private key
+	if _, _, err := cmdSet.ExportKey(true, false, false, "m/44'/60'/0'/0/0"); err == nil {
+		t.Fatal("exported a private wallet key")
+	}
+
+	hash := crypto.Keccak256([]byte("message"))
+
+	sig, err := cmdSet.SignWithPath(hash, "m/44'/60'/0'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(sig.PubKey(), pubKey) {
+		t.Fatalf("signed with %x instead of %x", sig.PubKey(), pubKey)
+	}
+
+	if err := cmdSet.RemoveKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	if card.KeyUID() != "" {
+		t.Fatalf("key %s not removed", card.KeyUID())
+	}
+}
+
+func TestEmulatedCardData(t *testing.T) {
+	card := newTestCard(t)
+	cmdSet := openTestCard(t, card)
+
+	if err := cmdSet.StoreData(keycard.P1StoreDataPublic, []byte{1, 2, 3}); err == nil {
+		t.Fatal("stored data without PIN")
+	}
+
+	if err := cmdSet.VerifyPIN(testPIN); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdSet.StoreData(keycard.P1StoreDataPublic, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := cmdSet.GetData(keycard.P1StoreDataPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, []byte{1, 2, 3}) {
+		t.Fatalf("unexpected data %x", data)
+	}
+
+	// public data is readable without secure channel
+	ch := io.NewNormalChannel(card)
+
+	if err := keycard.NewCommandSet(ch).Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := ch.Send(keycard.NewCommandGetData(keycard.P1StoreDataPublic))
+	if err != nil || resp.Sw != apdu.SwOK || !bytes.Equal(resp.Data, []byte{1, 2, 3}) {
+		t.Fatalf("unexpected response %+v %v", resp, err)
+	}
+}
+
+func TestEmulatedCardFactoryReset(t *testing.T) {
+	card := newTestCard(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	uid := card.InstanceUID()
+	cmdSet := keycard.NewCommandSet(io.NewNormalChannel(card))
+
+	if err := cmdSet.Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdSet.FactoryReset(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmdSet.Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	if cmdSet.ApplicationInfo.Initialized || card.KeyUID() != "" || card.InstanceUID() == uid {
+		t.Fatalf("card not reset %+v", cmdSet.ApplicationInfo)
+	}
+}
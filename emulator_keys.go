@@ -0,0 +1,157 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/keycard-go/derivationpath"
+)
+
+const hardenedIndex = 0x80000000
+
+var errInvalidChildKey = errors.New("invalid child key")
+
+// emulatedKey is a BIP32 extended private key.
+type emulatedKey struct {
+	key       []byte
+	chainCode []byte
+}
+
+func newMasterKey(seed []byte) (*emulatedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	i := mac.Sum(nil)
+
+	k := new(big.Int).SetBytes(i[:32])
+
+	if k.Sign() == 0 || k.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, errInvalidChildKey
+	}
+
+	return &emulatedKey{key: i[:32], chainCode: i[32:]}, nil
+}
+
+func (k *emulatedKey) privateKey() (*ecdsa.PrivateKey, error) {
+	return crypto.ToECDSA(k.key)
+}
+
+func (k *emulatedKey) child(index uint32) (*emulatedKey, error) {
+	mac := hmac.New(sha512.New, k.chainCode)
+
+	if index >= hardenedIndex {
+		mac.Write([]byte{0x00})
+		mac.Write(k.key)
+	} else {
+		priv, err := k.privateKey()
+
+		if err != nil {
+			return nil, err
+		}
+
+		mac.Write(crypto.CompressPubkey(&priv.PublicKey))
+	}
+
+	var idx [4]byte
+	binary.BigEndian.PutUint32(idx[:], index)
+	mac.Write(idx[:])
+	i := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	il := new(big.Int).SetBytes(i[:32])
+
+	if il.Cmp(n) >= 0 {
+		return nil, errInvalidChildKey
+	}
+
+	il.Add(il, new(big.Int).SetBytes(k.key))
+	il.Mod(il, n)
+
+	if il.Sign() == 0 {
+		return nil, errInvalidChildKey
+	}
+
+	return &emulatedKey{key: il.FillBytes(make([]byte, 32)), chainCode: i[32:]}, nil
+}
+
+func (k *emulatedKey) derive(path []uint32) (*emulatedKey, error) {
+	var err error
+	key := k
+
+	for _, index := range path {
+		key, err = key.child(index)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+func decodePath(data []byte) ([]uint32, bool) {
+	if len(data)%4 != 0 {
+		return nil, false
+	}
+
+	path := make([]uint32, len(data)/4)
+
+	for i := range path {
+		path[i] = binary.BigEndian.Uint32(data[4*i:])
+	}
+
+	return path, true
+}
+
+func encodePath(path []uint32) []byte {
+	data := make([]byte, 4*len(path))
+
+	for i, index := range path {
+		binary.BigEndian.PutUint32(data[4*i:], index)
+	}
+
+	return data
+}
+
+func isEIP1581Path(path []uint32) bool {
+	_, prefix, err := derivationpath.Decode(eip1581Path)
+
+	if err != nil || len(path) <= len(prefix) {
+		return false
+	}
+
+	return bytes.Equal(encodePath(path[:len(prefix)]), encodePath(prefix))
+}
+
+// generateMnemonicIndexes returns the BIP39 word indexes of a random mnemonic
+// whose checksum is checksumSize bits long.
+func generateMnemonicIndexes(checksumSize int) ([]uint16, error) {
+	entropy := make([]byte, checksumSize*4)
+
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(entropy)
+
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, uint(checksumSize))
+	bits.Or(bits, big.NewInt(int64(checksum[0]>>(8-checksumSize))))
+
+	indexes := make([]uint16, checksumSize*3)
+	mask := big.NewInt(0x7FF)
+
+	for i := len(indexes) - 1; i >= 0; i-- {
+		indexes[i] = uint16(new(big.Int).And(bits, mask).Uint64())
+		bits.Rsh(bits, 11)
+	}
+
+	return indexes, nil
+}
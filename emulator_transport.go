@@ -0,0 +1,266 @@
+package statuskeycardgo
+
+import (
+	"errors"
+	"sync"
+)
+
+const defaultEmulatorReader = "Keycard Emulator"
+
+var (
+	errEmulatorCancelled = errors.New("cancelled")
+	errEmulatorNoCard    = errors.New("no card in reader")
+	errEmulatorNoReader  = errors.New("unknown reader")
+)
+
+// Emulator is a Transport backend made of virtual readers in which
+// EmulatedCards can be inserted and removed at any time. Use its NewTransport
+// method as the TransportFactory of a flow.
+type Emulator struct {
+	mu      sync.Mutex
+	readers []string
+	cards   map[string]*EmulatedCard
+	changed chan struct{}
+}
+
+type emulatorTransport struct {
+	emulator *Emulator
+
+	mu sync.Mutex
+	// cancel is only set while a wait is pending, so that a Cancel which
+	// finds no waiter is not delivered to the next wait, as with PC/SC
+	cancel chan struct{}
+}
+
+type emulatorCard struct {
+	emulator *Emulator
+	reader   string
+	card     *EmulatedCard
+}
+
+// NewEmulator creates an emulator with the given readers, or with a single
+// reader if none is given.
+func NewEmulator(readers ...string) *Emulator {
+	if len(readers) == 0 {
+		readers = []string{defaultEmulatorReader}
+	}
+
+	return &Emulator{
+		readers: readers,
+		cards:   map[string]*EmulatedCard{},
+		changed: make(chan struct{}),
+	}
+}
+
+// Insert puts the card in the given reader, replacing any card already there.
+func (e *Emulator) Insert(reader string, card *EmulatedCard) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.hasReader(reader) {
+		return errEmulatorNoReader
+	}
+
+	card.resetSession()
+	e.cards[reader] = card
+	e.notify()
+
+	return nil
+}
+
+// Remove takes the card out of the given reader.
+func (e *Emulator) Remove(reader string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.hasReader(reader) {
+		return errEmulatorNoReader
+	}
+
+	if card, ok := e.cards[reader]; ok {
+		card.resetSession()
+		delete(e.cards, reader)
+		e.notify()
+	}
+
+	return nil
+}
+
+// PlugReader adds a reader to the emulator.
+func (e *Emulator) PlugReader(reader string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.hasReader(reader) {
+		e.readers = append(e.readers, reader)
+		e.notify()
+	}
+
+	return nil
+}
+
+// UnplugReader removes a reader, and the card inserted in it, from the
+// emulator.
+func (e *Emulator) UnplugReader(reader string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, r := range e.readers {
+		if r == reader {
+			if card, ok := e.cards[reader]; ok {
+				card.resetSession()
+				delete(e.cards, reader)
+			}
+
+			e.readers = append(e.readers[:i:i], e.readers[i+1:]...)
+			e.notify()
+
+			return nil
+		}
+	}
+
+	return errEmulatorNoReader
+}
+
+// NewTransport returns a Transport connected to this emulator. It has the
+// signature of a TransportFactory.
+func (e *Emulator) NewTransport() (Transport, error) {
+	return &emulatorTransport{emulator: e}, nil
+}
+
+func (e *Emulator) hasReader(reader string) bool {
+	for _, r := range e.readers {
+		if r == reader {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e *Emulator) notify() {
+	close(e.changed)
+	e.changed = make(chan struct{})
+}
+
+func (t *emulatorTransport) ListReaders() ([]string, error) {
+	t.emulator.mu.Lock()
+	defer t.emulator.mu.Unlock()
+
+	return append([]string{}, t.emulator.readers...), nil
+}
+
+func (t *emulatorTransport) WaitForCard(readers []string) (int, error) {
+	cancel := t.beginWait()
+	defer t.endWait()
+
+	for {
+		t.emulator.mu.Lock()
+		changed := t.emulator.changed
+
+		for i, r := range readers {
+			if t.emulator.cards[r] != nil {
+				t.emulator.mu.Unlock()
+				return i, nil
+			}
+		}
+
+		t.emulator.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-cancel:
+			return -1, &TransportError{errEmulatorCancelled}
+		}
+	}
+}
+
+func (t *emulatorTransport) WaitForChange(known []ReaderStatus) ([]ReaderStatus, error) {
+	cancel := t.beginWait()
+	defer t.endWait()
+
+	for {
+		t.emulator.mu.Lock()
+		changed := t.emulator.changed
+		current := make([]ReaderStatus, len(t.emulator.readers))
+
+		for i, r := range t.emulator.readers {
+			current[i] = ReaderStatus{Reader: r, CardPresent: t.emulator.cards[r] != nil}
+		}
+
+		t.emulator.mu.Unlock()
+
+		if !sameReaderStatus(known, current) {
+			return current, nil
+		}
+
+		select {
+		case <-changed:
+		case <-cancel:
+			return nil, &TransportError{errEmulatorCancelled}
+		}
+	}
+}
+
+func (t *emulatorTransport) Connect(reader string) (Card, error) {
+	t.emulator.mu.Lock()
+	defer t.emulator.mu.Unlock()
+
+	card := t.emulator.cards[reader]
+
+	if card == nil {
+		return nil, &TransportError{errEmulatorNoCard}
+	}
+
+	card.resetSession()
+
+	return &emulatorCard{emulator: t.emulator, reader: reader, card: card}, nil
+}
+
+func (t *emulatorTransport) Cancel() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cancel != nil {
+		close(t.cancel)
+		t.cancel = nil
+	}
+
+	return nil
+}
+
+func (t *emulatorTransport) beginWait() chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cancel = make(chan struct{})
+
+	return t.cancel
+}
+
+func (t *emulatorTransport) endWait() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cancel = nil
+}
+
+func (t *emulatorTransport) Release() error {
+	return nil
+}
+
+func (c *emulatorCard) Transmit(apdu []byte) ([]byte, error) {
+	c.emulator.mu.Lock()
+	present := c.emulator.cards[c.reader] == c.card
+	c.emulator.mu.Unlock()
+
+	if !present {
+		return nil, &TransportError{errEmulatorNoCard}
+	}
+
+	return c.card.Transmit(apdu)
+}
+
+func (c *emulatorCard) Disconnect() error {
+	return nil
+}
@@ -0,0 +1,220 @@
+package statuskeycardgo
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	testReader  = "reader"
+	testTimeout = 10 * time.Second
+)
+
+// newTestEmulator returns an emulator with an initialized card in testReader.
+func newTestEmulator(t *testing.T) (*Emulator, *EmulatedCard) {
+	t.Helper()
+
+	card := newTestCard(t)
+	emu := NewEmulator(testReader)
+
+	if err := emu.Insert(testReader, card); err != nil {
+		t.Fatal(err)
+	}
+
+	return emu, card
+}
+
+// waitInBackground runs wait in a goroutine and returns its error once done.
+func waitInBackground(wait func() error) chan error {
+	done := make(chan error, 1)
+
+	go func() { done <- wait() }()
+
+	return done
+}
+
+func expectWaitResult(t *testing.T, done chan error, cancelled bool) {
+	t.Helper()
+
+	select {
+	case err := <-done:
+		if cancelled != (err != nil) {
+			t.Fatalf("unexpected wait result %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("wait did not return")
+	}
+}
+
+func TestEmulatorReaders(t *testing.T) {
+	emu := NewEmulator()
+	transport, _ := emu.NewTransport()
+
+	readers, _ := transport.ListReaders()
+
+	if len(readers) != 1 || readers[0] != defaultEmulatorReader {
+		t.Fatalf("unexpected readers %v", readers)
+	}
+
+	if err := emu.Insert("unknown", newTestCard(t)); err == nil {
+		t.Fatal("inserted a card in an unknown reader")
+	}
+
+	if err := emu.PlugReader(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := emu.Insert(testReader, newTestCard(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := emu.UnplugReader(defaultEmulatorReader); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := transport.WaitForChange(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(status) != 1 || status[0] != (ReaderStatus{Reader: testReader, CardPresent: true}) {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestEmulatorWaitForCard(t *testing.T) {
+	emu := NewEmulator(testReader, "other")
+	transport, _ := emu.NewTransport()
+
+	index := make(chan int, 1)
+	done := waitInBackground(func() error {
+		i, err := transport.WaitForCard([]string{"other", testReader})
+		index <- i
+		return err
+	})
+
+	if err := emu.Insert(testReader, newTestCard(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	expectWaitResult(t, done, false)
+
+	if i := <-index; i != 1 {
+		t.Fatalf("unexpected reader index %d", i)
+	}
+}
+
+func TestEmulatorWaitForChange(t *testing.T) {
+	emu, _ := newTestEmulator(t)
+	transport, _ := emu.NewTransport()
+
+	known, err := transport.WaitForChange(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := waitInBackground(func() error {
+		current, err := transport.WaitForChange(known)
+
+		if err == nil && (len(current) != 1 || current[0].CardPresent) {
+			t.Errorf("unexpected status %+v", current)
+		}
+
+		return err
+	})
+
+	if err := emu.Remove(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	expectWaitResult(t, done, false)
+}
+
+func TestEmulatorCancel(t *testing.T) {
+	emu := NewEmulator(testReader)
+	transport, _ := emu.NewTransport()
+
+	done := waitInBackground(func() error {
+		_, err := transport.WaitForCard([]string{testReader})
+		return err
+	})
+
+	// the wait might not have started yet, keep cancelling until it returns
+	for {
+		_ = transport.Cancel()
+
+		select {
+		case err := <-done:
+			if _, ok := err.(*TransportError); !ok {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestEmulatorCancelWithoutWaiter(t *testing.T) {
+	emu := NewEmulator(testReader)
+	transport, _ := emu.NewTransport()
+
+	// a cancel with no pending wait must not abort the next one
+	_ = transport.Cancel()
+
+	done := waitInBackground(func() error {
+		_, err := transport.WaitForCard([]string{testReader})
+		return err
+	})
+
+	select {
+	case err := <-done:
+		t.Fatalf("stale cancel aborted the wait: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := emu.Insert(testReader, newTestCard(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	expectWaitResult(t, done, false)
+}
+
+func TestEmulatorCardRemoved(t *testing.T) {
+	emu, card := newTestEmulator(t)
+	transport, _ := emu.NewTransport()
+
+	conn, err := transport.Connect(testReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := emu.Remove(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.Transmit([]byte{0x00, 0xA4, 0x04, 0x00, 0x00}); err == nil {
+		t.Fatal("transmitted to a removed card")
+	}
+
+	if _, err := transport.Connect(testReader); err == nil {
+		t.Fatal("connected to an empty reader")
+	}
+
+	// reinserting the card does not revive the old connection
+	if err := emu.Insert(testReader, newTestCard(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.Transmit([]byte{0x00, 0xA4, 0x04, 0x00, 0x00}); err == nil {
+		t.Fatal("transmitted to another card")
+	}
+
+	if err := emu.Insert(testReader, card); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := conn.Transmit([]byte{0x00, 0xA4, 0x04, 0x00, 0x00}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,24 +1,166 @@
 package statuskeycardgo
 
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ebfe/scard"
+	keycard "github.com/status-im/keycard-go"
+	"github.com/status-im/keycard-go/apdu"
+	kcrypto "github.com/status-im/keycard-go/crypto"
+	"github.com/status-im/keycard-go/globalplatform"
+)
+
 const (
-	ErrorKey         = "error"
-	ErrorOK          = "ok"
-	ErrorCancel      = "cancel"
-	ErrorConnection  = "connection-error"
-	ErrorUnknownFlow = "unknown-flow"
-	ErrorNotAKeycard = "not-a-keycard"
-	ErrorNoKeys      = "no-keys"
-	ErrorHasKeys     = "has-keys"
-	ErrorRequireInit = "require-init"
-	ErrorPairing     = "pairing"
-	ErrorUnblocking  = "unblocking"
-	ErrorSigning     = "signing"
-	ErrorExporting   = "exporting"
-	ErrorChanging    = "changing-credentials"
-	ErrorLoading     = "loading-keys"
-	ErrorStoreMeta   = "storing-metadata"
-	ErrorNoData      = "no-data"
-	ErrorPCSC        = "no-pcsc"
-	ErrorReaderList  = "no-reader-list"
-	ErrorNoReader    = "no-reader-found"
+	ErrorKey          = "error"
+	ErrorOK           = "ok"
+	ErrorCancel       = "cancel"
+	ErrorConnection   = "connection-error"
+	ErrorUnknownFlow  = "unknown-flow"
+	ErrorNotAKeycard  = "not-a-keycard"
+	ErrorNoKeys       = "no-keys"
+	ErrorHasKeys      = "has-keys"
+	ErrorRequireInit  = "require-init"
+	ErrorPairing      = "pairing"
+	ErrorUnblocking   = "unblocking"
+	ErrorSigning      = "signing"
+	ErrorExporting    = "exporting"
+	ErrorChanging     = "changing-credentials"
+	ErrorLoading      = "loading-keys"
+	ErrorStoreMeta    = "storing-metadata"
+	ErrorNoData       = "no-data"
+	ErrorPCSC         = "no-pcsc"
+	ErrorReaderList   = "no-reader-list"
+	ErrorNoReader     = "no-reader-found"
+	ErrorInvalidParam = "invalid-param"
+	ErrorInternal     = "internal-error"
+	ErrorReaderBusy   = "reader-busy"
+	ErrorNoCard       = "no-card"
+	ErrorWrongPIN     = "wrong-pin"
+	ErrorWrongPUK     = "wrong-puk"
+	ErrorWrongPairing = "wrong-pairing"
+	ErrorCardLocked   = "card-locked"
+	ErrorNoFreeSlots  = "no-free-slots"
+	ErrorBadResponse  = "bad-response"
+	ErrorUnknown      = "unknown-error"
+	ErrorTimeout      = "timeout"
 )
+
+// FlowError is the structured form of a flow error, found under ErrorInfo in
+// FlowResult and in the actions paused after a failed command. Code is one of
+// the Error constants, SW the status word returned by the card, if any, and
+// RetriesLeft the remaining PIN or PUK attempts after a wrong one.
+type FlowError struct {
+	Code        string `json:"code"`
+	SW          uint16 `json:"sw,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+	RetriesLeft *int   `json:"retriesLeft,omitempty"`
+	Err         error  `json:"-"`
+}
+
+func newFlowError(code string, err error) *FlowError {
+	e := &FlowError{Code: code, Err: err}
+
+	if err != nil {
+		e.Detail = err.Error()
+	}
+
+	return e
+}
+
+func (e *FlowError) Error() string {
+	if e.Detail == "" {
+		return e.Code
+	}
+
+	return e.Code + ": " + e.Detail
+}
+
+func (e *FlowError) Unwrap() error {
+	return e.Err
+}
+
+var swCodes = map[uint16]string{
+	globalplatform.SwFileNotFound:                ErrorNotAKeycard,
+	globalplatform.SwAuthenticationMethodBlocked: ErrorCardLocked,
+	keycard.SwNoAvailablePairingSlots:            ErrorNoFreeSlots,
+}
+
+var scardCodes = map[scard.Error]string{
+	scard.ErrCancelled:          ErrorCancel,
+	scard.ErrSharingViolation:   ErrorReaderBusy,
+	scard.ErrNoSmartcard:        ErrorNoCard,
+	scard.ErrRemovedCard:        ErrorNoCard,
+	scard.ErrNoReadersAvailable: ErrorNoReader,
+	scard.ErrUnknownReader:      ErrorNoReader,
+	scard.ErrReaderUnavailable:  ErrorNoReader,
+	scard.ErrNoService:          ErrorPCSC,
+	scard.ErrServiceStopped:     ErrorPCSC,
+}
+
+// toFlowError classifies err. Errors of the card, of keycard-go and of PC/SC
+// are given a code of their own, anything else is ErrorUnknown.
+func toFlowError(err error) *FlowError {
+	var flowErr *FlowError
+	var panicErr *flowPanic
+	var wrongPIN *keycard.WrongPINError
+	var wrongPUK *keycard.WrongPUKError
+	var badResponse *apdu.ErrBadResponse
+	var scardErr scard.Error
+	var transportErr *TransportError
+
+	switch {
+	case errors.As(err, &flowErr):
+		return flowErr
+	case errors.As(err, &panicErr):
+		return &FlowError{Code: ErrorInternal, Detail: fmt.Sprint(panicErr.value), Err: err}
+	case errors.As(err, &wrongPIN):
+		return retriesError(ErrorWrongPIN, wrongPIN.RemainingAttempts, err)
+	case errors.As(err, &wrongPUK):
+		if wrongPUK.RemainingAttempts == 0 {
+			return retriesError(ErrorCardLocked, 0, err)
+		}
+
+		return retriesError(ErrorWrongPUK, wrongPUK.RemainingAttempts, err)
+	case errors.As(err, &badResponse):
+		code, ok := swCodes[badResponse.Sw]
+
+		if !ok {
+			code = ErrorBadResponse
+		}
+
+		e := newFlowError(code, err)
+		e.SW = badResponse.Sw
+
+		return e
+	case errors.As(err, &scardErr):
+		code, ok := scardCodes[scardErr]
+
+		if !ok {
+			code = ErrorConnection
+		}
+
+		e := newFlowError(code, err)
+		e.Detail = fmt.Sprintf("%s (0x%08x)", scardErr.Error(), uint32(scardErr))
+
+		return e
+	case errors.Is(err, kcrypto.ErrInvalidCardCryptogram):
+		return newFlowError(ErrorWrongPairing, err)
+	case errors.Is(err, keycard.ErrNoAvailablePairingSlots):
+		return newFlowError(ErrorNoFreeSlots, err)
+	case errors.As(err, &transportErr):
+		return newFlowError(ErrorConnection, err)
+	}
+
+	return newFlowError(ErrorUnknown, err)
+}
+
+// retriesError reports a wrong PIN or PUK. The card answers those with the SW
+// 0x63Cx, x being the remaining attempts.
+func retriesError(code string, retries int, err error) *FlowError {
+	e := newFlowError(code, err)
+	e.SW = 0x63c0 | uint16(retries)
+	e.RetriesLeft = &retries
+
+	return e
+}
@@ -0,0 +1,78 @@
+package statuskeycardgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ebfe/scard"
+	keycard "github.com/status-im/keycard-go"
+	"github.com/status-im/keycard-go/apdu"
+	kcrypto "github.com/status-im/keycard-go/crypto"
+)
+
+func TestToFlowError(t *testing.T) {
+	flowErr := &FlowError{Code: ErrorNoKeys}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected FlowError
+	}{
+		{"flow error", flowErr, *flowErr},
+		{"wrapped flow error", fmt.Errorf("loading: %w", flowErr), *flowErr},
+		{"panic", &flowPanic{value: "boom"}, FlowError{Code: ErrorInternal, Detail: "boom"}},
+		{"wrong PIN", &keycard.WrongPINError{RemainingAttempts: 2}, FlowError{Code: ErrorWrongPIN, SW: 0x63c2, RetriesLeft: intPtr(2)}},
+		{"wrong PIN no retries", &keycard.WrongPINError{RemainingAttempts: 0}, FlowError{Code: ErrorWrongPIN, SW: 0x63c0, RetriesLeft: intPtr(0)}},
+		{"wrong PUK", &keycard.WrongPUKError{RemainingAttempts: 4}, FlowError{Code: ErrorWrongPUK, SW: 0x63c4, RetriesLeft: intPtr(4)}},
+		{"wrong PUK locks", &keycard.WrongPUKError{RemainingAttempts: 0}, FlowError{Code: ErrorCardLocked, SW: 0x63c0, RetriesLeft: intPtr(0)}},
+		{"file not found", apdu.NewErrBadResponse(0x6a82, "select"), FlowError{Code: ErrorNotAKeycard, SW: 0x6a82}},
+		{"blocked", apdu.NewErrBadResponse(0x6983, "verify"), FlowError{Code: ErrorCardLocked, SW: 0x6983}},
+		{"no pairing slots", apdu.NewErrBadResponse(0x6a84, "pair"), FlowError{Code: ErrorNoFreeSlots, SW: 0x6a84}},
+		{"other SW", apdu.NewErrBadResponse(0x6985, "sign"), FlowError{Code: ErrorBadResponse, SW: 0x6985}},
+		{"scard cancelled", scard.ErrCancelled, FlowError{Code: ErrorCancel}},
+		{"scard removed", fmt.Errorf("transmit: %w", scard.ErrRemovedCard), FlowError{Code: ErrorNoCard}},
+		{"scard no service", scard.ErrNoService, FlowError{Code: ErrorPCSC}},
+		{"scard other", scard.ErrCommError, FlowError{Code: ErrorConnection}},
+		{"cryptogram", kcrypto.ErrInvalidCardCryptogram, FlowError{Code: ErrorWrongPairing}},
+		{"pairing slots", keycard.ErrNoAvailablePairingSlots, FlowError{Code: ErrorNoFreeSlots}},
+		{"transport", &TransportError{Err: errors.New("card removed")}, FlowError{Code: ErrorConnection}},
+		{"scard transport", &TransportError{Err: scard.ErrSharingViolation}, FlowError{Code: ErrorReaderBusy}},
+		{"unknown", errors.New("something"), FlowError{Code: ErrorUnknown}},
+	}
+
+	for _, test := range tests {
+		res := toFlowError(test.err)
+
+		if res.Code != test.expected.Code || res.SW != test.expected.SW || !reflect.DeepEqual(res.RetriesLeft, test.expected.RetriesLeft) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, res)
+		}
+
+		if test.expected.Detail != "" && res.Detail != test.expected.Detail {
+			t.Errorf("%s: expected detail %q, got %q", test.name, test.expected.Detail, res.Detail)
+		}
+	}
+}
+
+func TestToFlowErrorSCardDetail(t *testing.T) {
+	res := toFlowError(scard.ErrNoSmartcard)
+
+	if res.Detail != fmt.Sprintf("%s (0x8010000c)", scard.ErrNoSmartcard.Error()) {
+		t.Fatalf("unexpected detail %q", res.Detail)
+	}
+}
+
+func TestFlowErrorJSON(t *testing.T) {
+	res := toFlowError(&keycard.WrongPINError{RemainingAttempts: 0})
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"code":"wrong-pin","sw":25536,"detail":"wrong pin. remaining attempts: 0","retriesLeft":0}` {
+		t.Fatalf("unexpected JSON %s", data)
+	}
+}
@@ -2,6 +2,9 @@ package statuskeycardgo
 
 import (
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/status-im/status-keycard-go/signal"
@@ -15,16 +18,65 @@ type cardStatus struct {
 	pukRetries  int
 }
 
+// KeycardFlow runs one flow at a time on a dedicated goroutine. The run state
+// and the parameters are owned by mu: Start, Resume and Cancel may be called
+// from any goroutine, while the flow goroutine only touches the parameters when
+// the state is Running. Transitions are Idle -> Running on Start, Running ->
+// Paused when an action is signalled, Paused -> Resuming -> Running on Resume,
+// any -> Cancelling on Cancel and back to Idle when the flow ends. Timeouts
+// also move the flow to Cancelling, but end it with an ErrorTimeout result.
 type KeycardFlow struct {
-	flowType FlowType
-	state    runState
-	wakeUp   chan (struct{})
-	pairings *pairingStore
-	params   FlowParams
-	cardInfo cardStatus
+	// seq is the sequence number of the last signal, accessed atomically. It
+	// comes first to be 64-bit aligned on 32-bit platforms.
+	seq uint64
+
+	mu        sync.Mutex
+	flowType  FlowType
+	state     runState
+	wakeUp    chan (struct{})
+	cancelled chan (struct{})
+	done      chan (struct{})
+	notify    func(typ string, event interface{})
+	pairings  *pairingStore
+	params    FlowParams
+	cardInfo  cardStatus
+	transport TransportFactory
+	reader    string
+
+	readerFilter readerFilter
+	monitorMu    sync.Mutex
+	monitor      *readerMonitor
+	probing      chan (struct{})
+
+	connecting  bool
+	cardRemoved chan (struct{})
+	removedCard string
+	removedAct  string
+	connectErr  string
+
+	started    bool
+	pendingAct string
+	lastStatus FlowStatus
+
+	queue     []queuedFlow
+	requestID string
+	flowID    uint64
+
+	deadline      time.Time
+	deadlineTimer *time.Timer
+	timedOut      bool
+
+	sessionIdle time.Duration
+	session     *persistentSession
+
+	batch []*BatchSignature
 }
 
-func NewFlow(storageDir string) (*KeycardFlow, error) {
+func NewFlow(storageDir string, opts ...FlowOption) (*KeycardFlow, error) {
+	return NewFlowWithTransport(storageDir, NewPCSCTransport, opts...)
+}
+
+func NewFlowWithTransport(storageDir string, transport TransportFactory, opts ...FlowOption) (*KeycardFlow, error) {
 	p, err := newPairingStore(storageDir)
 
 	if err != nil {
@@ -32,85 +84,292 @@ func NewFlow(storageDir string) (*KeycardFlow, error) {
 	}
 
 	flow := &KeycardFlow{
-		wakeUp:   make(chan (struct{})),
-		pairings: p,
+		wakeUp:    make(chan (struct{}), 1),
+		pairings:  p,
+		transport: transport,
+	}
+
+	for _, opt := range opts {
+		opt(flow)
 	}
 
 	return flow, nil
 }
 
+// lastFlowID is the ID of the last flow started in the process, accessed
+// atomically.
+var lastFlowID uint64
+
 func (f *KeycardFlow) Start(flowType FlowType, params FlowParams) error {
+	_, _, err := f.start(flowType, params, nil)
+	return err
+}
+
+// start runs the flow, delivering its signals to notify, or to the signal
+// handler if nil. It returns the ID of the flow and the channel closed once
+// it ends.
+func (f *KeycardFlow) start(flowType FlowType, params FlowParams, notify func(typ string, event interface{})) (uint64, chan (struct{}), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	if f.state != Idle {
-		return errors.New("already running")
+		return 0, nil, errors.New("already running")
+	}
+
+	if params == nil {
+		params = FlowParams{}
 	}
 
+	if err := validateParams(flowType, params); err != nil {
+		return 0, nil, err
+	}
+
+	f.run(flowType, params, notify, "")
+
+	return f.flowID, f.done, nil
+}
+
+// run starts the flow goroutine on validated parameters. It must be called
+// with mu held and the flow Idle.
+func (f *KeycardFlow) run(flowType FlowType, params FlowParams, notify func(typ string, event interface{}), requestID string) {
+	f.prepare(flowType, params, notify, requestID)
+	go f.runFlow()
+}
+
+// prepare marks the flow Running on validated parameters, the caller starts
+// runFlow. It must be called with mu held and the flow Idle.
+func (f *KeycardFlow) prepare(flowType FlowType, params FlowParams, notify func(typ string, event interface{}), requestID string) {
 	f.flowType = flowType
 	f.params = params
+	f.requestID = requestID
+	f.cancelled = make(chan (struct{}))
+	f.done = make(chan (struct{}))
+	f.notify = notify
 	f.state = Running
-	go f.runFlow()
+	f.started = true
+	f.pendingAct = ""
+	f.lastStatus = nil
+	f.reader = ""
+	f.batch = nil
+	f.flowID = atomic.AddUint64(&lastFlowID, 1)
+
+	if notify == nil {
+		flowID := f.flowID
+		f.notify = func(typ string, event interface{}) { f.emit(flowID, typ, event) }
+	}
 
-	return nil
+	if timeout := secondsParam(params[FlowTimeout]); timeout > 0 {
+		cancelled := f.cancelled
+		f.deadline = time.Now().Add(timeout)
+		f.deadlineTimer = time.AfterFunc(timeout, func() { f.expire(cancelled, false) })
+	}
+}
+
+// emit sends a signal of the flow flowID, or a monitor signal if 0, to the
+// signal handler.
+func (f *KeycardFlow) emit(flowID uint64, typ string, event interface{}) {
+	signal.SendEnvelope(&signal.Envelope{
+		Type:   typ,
+		Event:  event,
+		FlowID: flowID,
+		Seq:    atomic.AddUint64(&f.seq, 1),
+	})
 }
 
 func (f *KeycardFlow) Resume(params FlowParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.resume(params)
+}
+
+// ResumeFlow is like Resume, but fails unless flowID is the running flow, so
+// that a late answer does not resume the next flow.
+func (f *KeycardFlow) ResumeFlow(flowID uint64, params FlowParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkFlowID(flowID); err != nil {
+		return err
+	}
+
+	return f.resume(params)
+}
+
+// resume must be called with mu held.
+func (f *KeycardFlow) resume(params FlowParams) error {
 	if f.state != Paused {
 		return errors.New("only paused flows can be resumed")
 	}
 
+	if f.connecting {
+		return errors.New("cannot resume while waiting for a card")
+	}
+
+	if err := validateParams(f.flowType, params); err != nil {
+		return err
+	}
+
 	for k, v := range params {
 		f.params[k] = v
 	}
 
 	f.state = Resuming
+	f.pendingAct = ""
 	f.wakeUp <- struct{}{}
 
 	return nil
 }
 
 func (f *KeycardFlow) Cancel() error {
-	prevState := f.state
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
-	if prevState == Idle {
+	if f.state == Idle {
 		return errors.New("cannot cancel idle flow")
 	}
 
-	f.state = Cancelling
-	if prevState == Paused {
-		f.wakeUp <- struct{}{}
+	f.cancel()
+
+	return nil
+}
+
+// CancelFlow is like Cancel, but fails unless flowID is the running flow.
+func (f *KeycardFlow) CancelFlow(flowID uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkFlowID(flowID); err != nil {
+		return err
+	}
+
+	f.cancel()
+
+	return nil
+}
+
+// checkFlowID must be called with mu held.
+func (f *KeycardFlow) checkFlowID(flowID uint64) error {
+	if f.state == Idle || f.flowID != flowID {
+		return fmt.Errorf("flow %d is not running", flowID)
 	}
 
 	return nil
 }
 
+// cancel must be called with mu held and the flow not Idle.
+func (f *KeycardFlow) cancel() {
+	if f.state != Cancelling {
+		f.state = Cancelling
+		close(f.cancelled)
+	}
+}
+
 func (f *KeycardFlow) runFlow() {
-	var result FlowStatus
-	var err error
+	f.waitProbe()
+
+	result, gaveUp := f.runConnectedFlow()
+
+	f.mu.Lock()
+
+	if f.deadlineTimer != nil {
+		f.deadlineTimer.Stop()
+	}
+
+	// a command running when the deadline expired might still have completed,
+	// its result is reported then
+	if f.timedOut && gaveUp {
+		result = FlowStatus{ErrorKey: ErrorTimeout, ErrorInfo: newFlowError(ErrorTimeout, nil)}
+	}
+
+	if f.reader != "" {
+		result[ReaderName] = f.reader
+	}
+
+	if f.requestID != "" {
+		result[RequestID] = f.requestID
+	}
+
+	cancelled := f.state == Cancelling && !f.timedOut
+	notify, done := f.notify, f.done
+	f.pendingAct = ""
+	if !cancelled {
+		f.lastStatus = copyStatus(result)
+	}
+	f.deadline = time.Time{}
+	f.deadlineTimer = nil
+	f.timedOut = false
+	f.params = nil
+	f.removedCard = ""
+	f.removedAct = ""
+	f.connectErr = ""
+	f.connecting = false
+
+	// a Resume racing with Cancel might have left a wake up behind
+	select {
+	case <-f.wakeUp:
+	default:
+	}
+
+	f.state = Idle
+
+	// the next enqueued flow takes over under the same lock, so that a Start
+	// cannot jump ahead of it, but only runs once the result is delivered
+	next := f.dequeue()
+	f.mu.Unlock()
+
+	// sent once Idle, so that the next flow can be started from the handler
+	// when none is enqueued
+	if !cancelled {
+		notify(FlowResult, result)
+	}
+
+	close(done)
+
+	if next {
+		go f.runFlow()
+	}
+}
+
+// runConnectedFlow runs the flow until it completes or gives up, gaveUp telling
+// which. A panic is turned into an internal error result, so that the host
+// process survives and the flow returns to Idle; the card is released by the
+// deferred closeKeycard of connectedFlow.
+func (f *KeycardFlow) runConnectedFlow() (result FlowStatus, gaveUp bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			p := recoveredPanic(r)
+			l("flow panicked: %v\n%s", p.value, p.stack)
+			result = p.status()
+		}
+	}()
 
 	for {
 		f.cardInfo = cardStatus{freeSlots: -1, pinRetries: -1, pukRetries: -1}
-		result, err = f.connectedFlow()
+		f.setReader("")
+		status, err := f.connectedFlow()
 
 		if _, ok := err.(*restartError); !ok {
-			if result == nil {
-				result = FlowStatus{ErrorKey: err.Error()}
+			_, gaveUp = err.(*giveupError)
+
+			if status == nil {
+				flowErr := toFlowError(err)
+				status = FlowStatus{ErrorKey: flowErr.Code, ErrorInfo: flowErr}
 				if f.cardInfo.freeSlots != -1 {
-					result[InstanceUID] = f.cardInfo.instanceUID
-					result[KeyUID] = f.cardInfo.keyUID
+					status[InstanceUID] = f.cardInfo.instanceUID
+					status[KeyUID] = f.cardInfo.keyUID
 				}
 			}
-			break
-		}
-	}
 
-	if f.state != Cancelling {
-		signal.Send(FlowResult, result)
+			return status, gaveUp
+		}
 	}
-
-	f.params = nil
-	f.state = Idle
 }
 
-func (f *KeycardFlow) pause(action string, errMsg string, status FlowParams) {
+// pause moves the flow to Paused before signalling the action, so that the
+// flow can be resumed as soon as the signal is delivered. It fails if the flow
+// has been cancelled.
+func (f *KeycardFlow) pause(action string, errMsg string, status FlowParams) error {
 	status[ErrorKey] = errMsg
 
 	if f.cardInfo.freeSlots != -1 {
@@ -124,17 +383,58 @@ func (f *KeycardFlow) pause(action string, errMsg string, status FlowParams) {
 		status[PUKRetries] = f.cardInfo.pukRetries
 	}
 
-	signal.Send(action, status)
+	f.mu.Lock()
+
+	if f.state == Cancelling {
+		f.mu.Unlock()
+		return giveupErr()
+	}
+
 	f.state = Paused
+	f.pendingAct = action
+	f.lastStatus = copyStatus(status)
+	f.mu.Unlock()
+
+	f.notify(action, status)
+
+	return nil
 }
 
 func (f *KeycardFlow) pauseAndWaitWithStatus(action string, errMsg string, status FlowParams) error {
-	if f.state == Cancelling {
-		return giveupErr()
+	clock := f.startPauseClock(action)
+	defer clock.stop()
+
+	if err := f.pause(action, errMsg, status); err != nil {
+		return err
+	}
+
+	// the card is expected to be taken out when swapping
+	removed := f.cardRemoved
+	if action == SwapCard {
+		removed = nil
+	}
+
+	for woken := false; !woken; {
+		select {
+		case <-f.wakeUp:
+			woken = true
+		case <-f.cancelled:
+			return giveupErr()
+		case <-removed:
+			return f.waitForReinsertion(action)
+		case <-clock.heartbeat:
+			f.beat(clock)
+		case <-clock.timeout:
+			if err := f.pauseExpired(); err != nil {
+				return err
+			}
+
+			woken = true
+		}
 	}
 
-	f.pause(action, errMsg, status)
-	<-f.wakeUp
+	f.mu.Lock()
+	defer f.mu.Unlock()
 
 	if f.state == Resuming {
 		f.state = Running
@@ -144,6 +444,42 @@ func (f *KeycardFlow) pauseAndWaitWithStatus(action string, errMsg string, statu
 	}
 }
 
+// waitForReinsertion signals the removal of the card while the flow waits on
+// action. The flow stays paused and restarts into the same action once the card
+// is inserted again.
+func (f *KeycardFlow) waitForReinsertion(action string) error {
+	f.mu.Lock()
+
+	switch f.state {
+	case Paused:
+		// parameters can only be given once the card is back
+		f.connecting = true
+	case Resuming:
+		// resumed just as the card was removed, restart right away
+		<-f.wakeUp
+		f.state = Running
+		f.mu.Unlock()
+		return restartErr()
+	default:
+		f.mu.Unlock()
+		return giveupErr()
+	}
+
+	f.mu.Unlock()
+
+	f.removedCard = f.cardInfo.instanceUID
+	f.removedAct = action
+
+	f.notify(CardRemoved, FlowStatus{
+		PendingAct:  action,
+		ReaderName:  f.reader,
+		InstanceUID: f.cardInfo.instanceUID,
+		KeyUID:      f.cardInfo.keyUID,
+	})
+
+	return restartErr()
+}
+
 func (f *KeycardFlow) pauseAndWait(action string, errMsg string) error {
 	return f.pauseAndWaitWithStatus(action, errMsg, FlowParams{})
 }
@@ -171,7 +507,7 @@ func (f *KeycardFlow) requireNoKeys() error {
 		return nil
 	}
 
-	if overwrite, ok := f.params[Overwrite]; ok && overwrite.(bool) {
+	if f.params.boolValue(Overwrite) {
 		return nil
 	}
 
@@ -179,66 +515,193 @@ func (f *KeycardFlow) requireNoKeys() error {
 }
 
 func (f *KeycardFlow) closeKeycard(kc *keycardContext) {
+	f.cardRemoved = nil
+
 	if kc != nil {
 		kc.stop()
 	}
 }
 
 func (f *KeycardFlow) connect() (*keycardContext, error) {
-	kc, err := startKeycardContext()
+	filter := f.readerFilter
+
+	if requested, ok := f.params.stringValue(ReaderName); ok {
+		filter.requested = requested
+	}
+
+	f.setConnecting(true)
+	defer f.setConnecting(false)
+
+	kc, err := startKeycardContext(f.transport, filter)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// kc is not returned when signalling panics, release it here
+	release := kc.cancel
+	defer func() {
+		if r := recover(); r != nil {
+			release()
+			panic(recoveredPanic(r))
+		}
+	}()
+
 	t := time.NewTimer(150 * time.Millisecond)
+	defer t.Stop()
+
+	clock := &pauseClock{}
+	if f.removedAct != "" {
+		// the pending action now waits for the card to be back
+		clock = f.startPauseClock(f.removedAct)
+	}
+	defer func() { clock.stop() }()
 
 	for {
 		select {
-		case <-f.wakeUp:
-			if f.state != Cancelling {
-				panic("Resuming is not expected during connection")
-			}
+		case <-f.cancelled:
+			kc.cancel()
 			return nil, giveupErr()
+		case <-clock.heartbeat:
+			f.beat(clock)
+		case <-clock.timeout:
+			// Resume is refused while connecting, the flow is still paused
+			// unless cancelled meanwhile
+			if f.expire(f.cancelled, true) {
+				kc.cancel()
+				return nil, giveupErr()
+			}
 		case <-kc.connected:
+			if kc.panicked != nil {
+				panic(kc.panicked)
+			}
 			if kc.runErr != nil {
-				return nil, restartErr()
+				return nil, f.connectionFailed(kc.runErr)
 			}
-			t.Stop()
-			if f.state == Paused {
+			release = kc.stop
+			f.connectErr = ""
+			f.cardRemoved = kc.removed
+
+			f.mu.Lock()
+			f.reader = kc.reader
+			inserted := f.state == Paused
+			if inserted {
 				f.state = Running
-				signal.Send(CardInserted, FlowStatus{})
+				f.pendingAct = ""
+			}
+			f.mu.Unlock()
+
+			if inserted {
+				f.notify(CardInserted, FlowStatus{ReaderName: kc.reader})
 			}
 
 			return kc, nil
 		case <-t.C:
-			f.pause(InsertCard, ErrorConnection, FlowParams{})
+			if f.removedAct != "" {
+				// CardRemoved has already been signalled
+				continue
+			}
+
+			status := FlowParams{}
+			if len(kc.readers) == 1 {
+				status[ReaderName] = kc.readers[0]
+			}
+
+			if err := f.pause(InsertCard, ErrorConnection, status); err != nil {
+				kc.cancel()
+				return nil, err
+			}
+
+			clock = f.startPauseClock(InsertCard)
 		}
 	}
 }
 
-func (f *KeycardFlow) connectedFlow() (FlowStatus, error) {
-	kc, err := f.connect()
-	defer f.closeKeycard(kc)
+// connectionFailed signals InsertCard with the reason the card cannot be used,
+// like another application holding the reader, and restarts the flow after a
+// while.
+func (f *KeycardFlow) connectionFailed(err error) error {
+	flowErr := toFlowError(err)
 
-	if err != nil {
-		return nil, err
+	if f.removedAct == "" && flowErr.Code != f.connectErr {
+		f.connectErr = flowErr.Code
+
+		if err := f.pause(InsertCard, ErrorConnection, FlowParams{ErrorInfo: flowErr}); err != nil {
+			return err
+		}
 	}
 
-	if factoryReset, ok := f.params[FactoryReset]; ok && factoryReset.(bool) {
-		err := f.factoryReset(kc)
+	select {
+	case <-f.cancelled:
+		return giveupErr()
+	case <-time.After(500 * time.Millisecond):
+		return restartErr()
+	}
+}
+
+// setReader is used by the flow goroutine, which can read the reader without
+// locking.
+func (f *KeycardFlow) setReader(reader string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reader = reader
+}
+
+func (f *KeycardFlow) setConnecting(connecting bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.connecting = connecting
+}
+
+func (f *KeycardFlow) connectedFlow() (FlowStatus, error) {
+	// the connection is closed once the flow ends, unless kept as persistent
+	// session
+	kc := f.resumeSession()
+	kept := false
+
+	defer func() {
+		if kept {
+			f.cardRemoved = nil
+		} else {
+			f.closeKeycard(kc)
+		}
+	}()
+
+	if kc == nil {
+		var err error
+		kc, err = f.connect()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if f.params.boolValue(FactoryReset) {
+			err := f.factoryReset(kc)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = f.selectKeycard(kc)
 
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	err = f.selectKeycard(kc)
+	status, err := f.flowTypeFlow(kc)
 
-	if err != nil {
-		return nil, err
+	if err == nil {
+		kept = f.keepSession(kc)
 	}
 
+	return status, err
+}
+
+func (f *KeycardFlow) flowTypeFlow(kc *keycardContext) (FlowStatus, error) {
 	switch f.flowType {
 	case GetAppInfo:
 		return f.getAppInfoFlow(kc)
@@ -268,8 +731,22 @@ func (f *KeycardFlow) connectedFlow() (FlowStatus, error) {
 		return f.storeMetadataFlow(kc)
 	case GetMetadata:
 		return f.getMetadataFlow(kc)
+	case Session:
+		return f.sessionFlow(kc)
+	case SignTransaction:
+		return f.signTransactionFlow(kc)
+	case SignTypedData:
+		return f.signTypedDataFlow(kc)
+	case SignMessage:
+		return f.signMessageFlow(kc)
+	case SignBatch:
+		return f.signBatchFlow(kc)
 	default:
-		return nil, errors.New(ErrorUnknownFlow)
+		if handler := registeredHandler(f.flowType); handler != nil {
+			return handler(&FlowContext{f, kc})
+		}
+
+		return nil, newFlowError(ErrorUnknownFlow, nil)
 	}
 }
 
@@ -303,6 +780,10 @@ func (f *KeycardFlow) exportKeysFlow(kc *keycardContext, recover bool) (FlowStat
 		return nil, err
 	}
 
+	return f.exportKeys(kc, recover)
+}
+
+func (f *KeycardFlow) exportKeys(kc *keycardContext, recover bool) (FlowStatus, error) {
 	result := FlowStatus{KeyUID: f.cardInfo.keyUID, InstanceUID: f.cardInfo.instanceUID}
 
 	key, err := f.exportKey(kc, encryptionPath, false)
@@ -359,9 +840,13 @@ func (f *KeycardFlow) exportPublicFlow(kc *keycardContext) (FlowStatus, error) {
 		return nil, err
 	}
 
+	return f.exportPublic(kc)
+}
+
+func (f *KeycardFlow) exportPublic(kc *keycardContext) (FlowStatus, error) {
 	result := FlowStatus{KeyUID: f.cardInfo.keyUID, InstanceUID: f.cardInfo.instanceUID}
 
-	if exportMaster, ok := f.params[ExportMaster]; ok && exportMaster.(bool) {
+	if f.params.boolValue(ExportMaster) {
 		masterKey, err := f.exportKey(kc, masterPath, true)
 		result[MasterAddr] = masterKey.Address
 
@@ -425,6 +910,54 @@ func (f *KeycardFlow) signFlow(kc *keycardContext) (FlowStatus, error) {
 	return FlowStatus{KeyUID: f.cardInfo.keyUID, InstanceUID: f.cardInfo.instanceUID, TXSignature: signature}, nil
 }
 
+func (f *KeycardFlow) signTransactionFlow(kc *keycardContext) (FlowStatus, error) {
+	err := f.requireKeys()
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.openSCAndAuthenticate(kc, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.signTransaction(kc)
+}
+
+func (f *KeycardFlow) signTypedDataFlow(kc *keycardContext) (FlowStatus, error) {
+	err := f.requireKeys()
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.openSCAndAuthenticate(kc, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.signTypedData(kc)
+}
+
+func (f *KeycardFlow) signMessageFlow(kc *keycardContext) (FlowStatus, error) {
+	err := f.requireKeys()
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.openSCAndAuthenticate(kc, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.signMessage(kc)
+}
+
 func (f *KeycardFlow) changePINFlow(kc *keycardContext) (FlowStatus, error) {
 	err := f.openSCAndAuthenticate(kc, false)
 
@@ -564,7 +1097,7 @@ func (f *KeycardFlow) getMetadataFlow(kc *keycardContext) (FlowStatus, error) {
 
 	result := FlowStatus{InstanceUID: f.cardInfo.instanceUID, KeyUID: f.cardInfo.keyUID}
 
-	if resolveAddr, ok := f.params[ResolveAddr]; ok && resolveAddr.(bool) {
+	if f.params.boolValue(ResolveAddr) {
 		if f.cardInfo.keyUID == "" {
 			return FlowStatus{ErrorKey: ErrorNoKeys, InstanceUID: f.cardInfo.instanceUID, KeyUID: f.cardInfo.keyUID, CardMeta: m}, nil
 		}
@@ -575,7 +1108,7 @@ func (f *KeycardFlow) getMetadataFlow(kc *keycardContext) (FlowStatus, error) {
 			return nil, err
 		}
 
-		if exportMaster, ok := f.params[ExportMaster]; ok && exportMaster.(bool) {
+		if f.params.boolValue(ExportMaster) {
 			masterKey, err := f.exportKey(kc, masterPath, true)
 			result[MasterAddr] = masterKey.Address
 
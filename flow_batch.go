@@ -0,0 +1,101 @@
+package statuskeycardgo
+
+import (
+	"errors"
+)
+
+// signBatchFlow signs the SignItems in order under a single authentication,
+// signalling SignProgress after each of them. Items which cannot be signed
+// get an error in the result instead of failing the flow. If the card is
+// removed, the flow goes on from the first item left once a card with the
+// same keys is back.
+func (f *KeycardFlow) signBatchFlow(kc *keycardContext) (FlowStatus, error) {
+	err := f.requireKeys()
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.openSCAndAuthenticate(kc, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.signBatch(kc)
+}
+
+func (f *KeycardFlow) signBatch(kc *keycardContext) (FlowStatus, error) {
+	items, ok := f.params.objectList(SignItems)
+
+	if !ok || len(items) == 0 {
+		err := f.pauseAndWait(EnterSignItems, ErrorSigning)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.signBatch(kc)
+	}
+
+	for i := len(f.batch); i < len(items); i++ {
+		signature, err := f.signBatchItem(kc, items[i])
+		progress := FlowStatus{ItemIndex: i, ItemCount: len(items)}
+
+		switch err.(type) {
+		case nil:
+			f.batch = append(f.batch, &BatchSignature{Signature: signature})
+			progress[TXSignature] = signature
+		case *restartError, *giveupError:
+			return nil, err
+		default:
+			if isSCardError(err) {
+				return nil, restartErr()
+			}
+
+			flowErr := toFlowError(err)
+			f.batch = append(f.batch, &BatchSignature{Error: flowErr})
+			progress[ErrorInfo] = flowErr
+		}
+
+		// the items left must be signed by the same card after a restart
+		if _, ok := f.params[KeyUID]; !ok {
+			f.params[KeyUID] = f.cardInfo.keyUID
+		}
+
+		f.notify(SignProgress, progress)
+	}
+
+	return FlowStatus{KeyUID: f.cardInfo.keyUID, InstanceUID: f.cardInfo.instanceUID, Signatures: f.batch}, nil
+}
+
+func (f *KeycardFlow) signBatchItem(kc *keycardContext, item FlowParams) (*Signature, error) {
+	path, ok := item.stringValue(BIP44Path)
+
+	if !ok {
+		path, ok = f.params.stringValue(BIP44Path)
+	}
+
+	if !ok {
+		return nil, newFlowError(ErrorInvalidParam, errors.New("missing bip44-path"))
+	}
+
+	hash, ok := item.stringValue(TXHash)
+
+	if !ok {
+		return nil, newFlowError(ErrorInvalidParam, errors.New("missing tx-hash"))
+	}
+
+	rawHash, err := xtob(hash)
+
+	if err != nil {
+		return nil, newFlowError(ErrorInvalidParam, err)
+	}
+
+	signature, err := kc.signWithPath(rawHash, path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.toChainSignature(rawHash, signature), nil
+}
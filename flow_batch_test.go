@@ -0,0 +1,149 @@
+package statuskeycardgo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignBatchMixedResults(t *testing.T) {
+	signals := recordSignals(t)
+	emu, card := newTestEmulator(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestFlowWithTransport(t, emu.NewTransport)
+
+	params := FlowParams{
+		PIN:       testPIN,
+		BIP44Path: "m/44'/60'/0'/0/0",
+		SignItems: []interface{}{
+			map[string]interface{}{TXHash: testHash},
+			map[string]interface{}{TXHash: "zz"},
+			map[string]interface{}{},
+			map[string]interface{}{TXHash: testHash, BIP44Path: "m/44'/60'/0'/0/1"},
+		},
+	}
+
+	if err := f.Start(SignBatch, params); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []FlowStatus
+
+	for len(progress) < 4 {
+		progress = append(progress, waitForSignal(t, signals, SignProgress))
+	}
+
+	status := waitForSignal(t, signals, FlowResult)
+	result := &SignBatchResult{}
+
+	if err := FromFlowStatus(status, result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Signatures) != 4 {
+		t.Fatalf("unexpected result %+v", status)
+	}
+
+	for i, signed := range []bool{true, false, false, true} {
+		progress := progress[i]
+		s := result.Signatures[i]
+
+		if progress[ItemIndex] != float64(i) || progress[ItemCount] != float64(4) {
+			t.Fatalf("unexpected progress %+v", progress)
+		}
+
+		// the progress of an item and its outcome in the result spell the
+		// signature and the error the same way
+		if _, ok := progress[TXSignature]; ok != signed || (s.Signature != nil) != signed {
+			t.Errorf("item %d: signature %+v in progress %+v", i, s.Signature, progress)
+		}
+
+		if _, ok := progress[ErrorInfo]; ok == signed || (s.Error != nil) == signed {
+			t.Errorf("item %d: error %+v in progress %+v", i, s.Error, progress)
+		}
+
+		if !signed && s.Error.Code != ErrorInvalidParam {
+			t.Errorf("item %d: unexpected error %+v", i, s.Error)
+		}
+	}
+
+	if result.Signatures[0].Signature.Address != testAddress || result.Signatures[3].Signature.Address == testAddress {
+		t.Fatalf("signed with the wrong keys %+v %+v", result.Signatures[0].Signature, result.Signatures[3].Signature)
+	}
+}
+
+func TestSignBatchKeepsKeysAfterRestart(t *testing.T) {
+	signals := recordSignals(t)
+	emu, card := newTestEmulator(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	other := newTestCard(t)
+
+	if err := other.LoadMnemonic("legal winner thank year wave sausage worth useful legal winner thank yellow", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestFlowWithTransport(t, slowTransports(emu.NewTransport, 20*time.Millisecond))
+
+	items := make([]interface{}, 8)
+
+	for i := range items {
+		items[i] = map[string]interface{}{TXHash: testHash}
+	}
+
+	if err := f.Start(SignBatch, FlowParams{PIN: testPIN, BIP44Path: "m/44'/60'/0'/0/0", SignItems: items}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, SignProgress)
+
+	if err := emu.Remove(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, InsertCard)
+
+	// a card with other keys cannot sign the items left
+	if err := emu.Insert(testReader, other); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := waitForSignal(t, signals, SwapCard); status[ErrorKey] != KeyUID {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	if err := emu.Remove(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := emu.Insert(testReader, card); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Resume(FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	status := waitForSignal(t, signals, FlowResult)
+	result := &SignBatchResult{}
+
+	if err := FromFlowStatus(status, result); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Signatures) != len(items) || result.KeyUID != card.KeyUID() {
+		t.Fatalf("unexpected result %+v", status)
+	}
+
+	for i, s := range result.Signatures {
+		if s.Signature == nil || s.Signature.Address != testAddress {
+			t.Fatalf("item %d: unexpected outcome %+v", i, s)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package statuskeycardgo
 
 import (
+	"bytes"
 	"errors"
 	"io"
+	"math/big"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/status-im/keycard-go/apdu"
 	"github.com/status-im/keycard-go/derivationpath"
 	ktypes "github.com/status-im/keycard-go/types"
@@ -38,13 +42,23 @@ func (f *KeycardFlow) selectKeycard(kc *keycardContext) error {
 		return f.pauseAndRestart(SwapCard, ErrorNotAKeycard)
 	}
 
-	if requiredInstanceUID, ok := f.params[InstanceUID]; ok {
+	if f.removedAct != "" {
+		removedCard := f.removedCard
+		f.removedCard = ""
+		f.removedAct = ""
+
+		if f.cardInfo.instanceUID != removedCard {
+			return f.pauseAndRestart(SwapCard, InstanceUID)
+		}
+	}
+
+	if requiredInstanceUID, ok := f.params.stringValue(InstanceUID); ok {
 		if f.cardInfo.instanceUID != requiredInstanceUID {
 			return f.pauseAndRestart(SwapCard, InstanceUID)
 		}
 	}
 
-	if requiredKeyUID, ok := f.params[KeyUID]; ok {
+	if requiredKeyUID, ok := f.params.stringValue(KeyUID); ok {
 		if f.cardInfo.keyUID != requiredKeyUID {
 			return f.pauseAndRestart(SwapCard, KeyUID)
 		}
@@ -58,13 +72,13 @@ func (f *KeycardFlow) pair(kc *keycardContext) error {
 		return f.pauseAndRestart(SwapCard, FreeSlots)
 	}
 
-	pairingPass, ok := f.params[PairingPass]
+	pairingPass, ok := f.params.stringValue(PairingPass)
 
 	if !ok {
 		pairingPass = DefPairing
 	}
 
-	pairing, err := kc.pair(pairingPass.(string))
+	pairing, err := kc.pair(pairingPass)
 
 	if err == nil {
 		return f.pairings.store(f.cardInfo.instanceUID, toPairInfo(pairing))
@@ -74,7 +88,7 @@ func (f *KeycardFlow) pair(kc *keycardContext) error {
 
 	delete(f.params, PairingPass)
 
-	err = f.pauseAndWait(EnterPairing, ErrorPairing)
+	err = f.pauseAndWaitWithStatus(EnterPairing, ErrorPairing, FlowParams{ErrorInfo: toFlowError(err)})
 
 	if err != nil {
 		return err
@@ -84,7 +98,7 @@ func (f *KeycardFlow) pair(kc *keycardContext) error {
 }
 
 func (f *KeycardFlow) initCard(kc *keycardContext) error {
-	newPIN, pinOK := f.params[NewPIN]
+	newPIN, pinOK := f.params.stringValue(NewPIN)
 
 	if !pinOK {
 		err := f.pauseAndWait(EnterNewPIN, ErrorRequireInit)
@@ -95,7 +109,7 @@ func (f *KeycardFlow) initCard(kc *keycardContext) error {
 		return f.initCard(kc)
 	}
 
-	newPUK, pukOK := f.params[NewPUK]
+	newPUK, pukOK := f.params.stringValue(NewPUK)
 	if !pukOK {
 		err := f.pauseAndWait(EnterNewPUK, ErrorRequireInit)
 		if err != nil {
@@ -105,12 +119,12 @@ func (f *KeycardFlow) initCard(kc *keycardContext) error {
 		return f.initCard(kc)
 	}
 
-	newPairing, pairingOK := f.params[NewPairing]
+	newPairing, pairingOK := f.params.stringValue(NewPairing)
 	if !pairingOK {
 		newPairing = DefPairing
 	}
 
-	err := kc.init(newPIN.(string), newPUK.(string), newPairing.(string))
+	err := kc.init(newPIN, newPUK, newPairing)
 
 	if isSCardError(err) {
 		return restartErr()
@@ -177,13 +191,14 @@ func (f *KeycardFlow) unblockPIN(kc *keycardContext) error {
 	}
 
 	pukError := ""
+	status := FlowParams{}
 	var err error
 
-	newPIN, pinOK := f.params[NewPIN]
-	puk, pukOK := f.params[PUK]
+	newPIN, pinOK := f.params.stringValue(NewPIN)
+	puk, pukOK := f.params.stringValue(PUK)
 
 	if pinOK && pukOK {
-		err = kc.unblockPIN(puk.(string), newPIN.(string))
+		err = kc.unblockPIN(puk, newPIN)
 
 		if err == nil {
 			f.cardInfo.pinRetries = maxPINRetries
@@ -201,6 +216,7 @@ func (f *KeycardFlow) unblockPIN(kc *keycardContext) error {
 		}
 
 		pukError = PUK
+		status[ErrorInfo] = toFlowError(err)
 	}
 
 	if f.cardInfo.pukRetries == 0 {
@@ -208,7 +224,7 @@ func (f *KeycardFlow) unblockPIN(kc *keycardContext) error {
 	}
 
 	if !pukOK {
-		err = f.pauseAndWait(EnterPUK, pukError)
+		err = f.pauseAndWaitWithStatus(EnterPUK, pukError, status)
 	} else if !pinOK {
 		err = f.pauseAndWait(EnterNewPIN, ErrorUnblocking)
 	}
@@ -227,9 +243,10 @@ func (f *KeycardFlow) authenticate(kc *keycardContext) error {
 	}
 
 	pinError := ""
+	status := FlowParams{}
 
-	if pin, ok := f.params[PIN]; ok {
-		err := kc.verifyPin(pin.(string))
+	if pin, ok := f.params.stringValue(PIN); ok {
+		err := kc.verifyPin(pin)
 
 		if err == nil {
 			f.cardInfo.pinRetries = maxPINRetries
@@ -242,13 +259,14 @@ func (f *KeycardFlow) authenticate(kc *keycardContext) error {
 		}
 
 		pinError = PIN
+		status[ErrorInfo] = toFlowError(err)
 	}
 
 	if f.cardInfo.pinRetries == 0 {
 		return f.unblockPIN(kc)
 	}
 
-	err := f.pauseAndWait(EnterPIN, pinError)
+	err := f.pauseAndWaitWithStatus(EnterPIN, pinError, status)
 
 	if err != nil {
 		return err
@@ -258,17 +276,30 @@ func (f *KeycardFlow) authenticate(kc *keycardContext) error {
 }
 
 func (f *KeycardFlow) openSCAndAuthenticate(kc *keycardContext, giveup bool) error {
+	if kc.authenticated {
+		return nil
+	}
+
 	err := f.openSC(kc, giveup)
 
 	if err != nil {
 		return err
 	}
 
-	return f.authenticate(kc)
+	err = f.authenticate(kc)
+
+	if err != nil {
+		return err
+	}
+
+	kc.authenticated = true
+
+	return nil
 }
 
 func (f *KeycardFlow) unpairCurrent(kc *keycardContext) error {
 	err := kc.unpairCurrent()
+	kc.authenticated = false
 
 	if isSCardError(err) {
 		return restartErr()
@@ -306,19 +337,19 @@ func (f *KeycardFlow) getMetadata(kc *keycardContext) (*Metadata, error) {
 		return nil, restartErr()
 	} else if serr, ok := err.(*apdu.ErrBadResponse); ok {
 		if serr.Sw == 0x6d00 {
-			return nil, errors.New(ErrorNoKeys)
+			return nil, newFlowError(ErrorNoKeys, nil)
 		} else {
 			return nil, err
 		}
 	} else if err == io.EOF {
-		return nil, errors.New(ErrorNoData)
+		return nil, newFlowError(ErrorNoData, nil)
 	} else {
 		return nil, err
 	}
 }
 
 func (f *KeycardFlow) storeMetadata(kc *keycardContext) error {
-	cardName, cardNameOK := f.params[CardName]
+	cardName, cardNameOK := f.params.stringValue(CardName)
 
 	if !cardNameOK {
 		err := f.pauseAndWait(EnterName, ErrorStoreMeta)
@@ -329,7 +360,7 @@ func (f *KeycardFlow) storeMetadata(kc *keycardContext) error {
 		return f.storeMetadata(kc)
 	}
 
-	w, walletsOK := f.params[WalletPaths]
+	wallets, walletsOK := f.params.stringList(WalletPaths)
 
 	if !walletsOK {
 		err := f.pauseAndWait(EnterWallets, ErrorStoreMeta)
@@ -340,23 +371,21 @@ func (f *KeycardFlow) storeMetadata(kc *keycardContext) error {
 		return f.storeMetadata(kc)
 	}
 
-	wallets := w.([]interface{})
-
 	paths := make([]uint32, len(wallets))
 	for i, p := range wallets {
-		if !strings.HasPrefix(p.(string), walletRoothPath) {
-			return errors.New("path must start with " + walletRoothPath)
+		if !strings.HasPrefix(p, walletRoothPath) {
+			return newFlowError(ErrorInvalidParam, errors.New("path must start with "+walletRoothPath))
 		}
 
-		_, components, err := derivationpath.Decode(p.(string))
+		_, components, err := derivationpath.Decode(p)
 		if err != nil {
-			return err
+			return newFlowError(ErrorInvalidParam, err)
 		}
 
 		paths[i] = components[len(components)-1]
 	}
 
-	m, err := ktypes.NewMetadata(cardName.(string), paths)
+	m, err := ktypes.NewMetadata(cardName, paths)
 	if err != nil {
 		return err
 	}
@@ -381,17 +410,16 @@ func (f *KeycardFlow) exportKey(kc *keycardContext, path string, onlyPublic bool
 }
 
 func (f *KeycardFlow) exportBIP44Key(kc *keycardContext) (interface{}, error) {
-	if path, ok := f.params[BIP44Path]; ok {
-		exportPrivParam, ok := f.params[ExportPriv]
-		exportPrivate := (!ok || !exportPrivParam.(bool))
+	if _, ok := f.params[BIP44Path]; ok {
+		exportPrivate := !f.params.boolValue(ExportPriv)
 
-		if pathStr, ok := path.(string); ok {
+		if pathStr, ok := f.params.stringValue(BIP44Path); ok {
 			return f.exportKey(kc, pathStr, exportPrivate)
-		} else if paths, ok := path.([]interface{}); ok {
+		} else if paths, ok := f.params.stringList(BIP44Path); ok {
 			keys := make([]*KeyPair, len(paths))
 
 			for i, path := range paths {
-				key, err := f.exportKey(kc, path.(string), exportPrivate)
+				key, err := f.exportKey(kc, path, exportPrivate)
 				if err != nil {
 					return nil, err
 				}
@@ -416,8 +444,8 @@ func (f *KeycardFlow) exportBIP44Key(kc *keycardContext) (interface{}, error) {
 }
 
 func (f *KeycardFlow) loadKeys(kc *keycardContext) error {
-	if mnemonic, ok := f.params[Mnemonic]; ok {
-		keyUID, err := kc.loadMnemonic(mnemonic.(string), "")
+	if mnemonic, ok := f.params.stringValue(Mnemonic); ok {
+		keyUID, err := kc.loadMnemonic(mnemonic, "")
 
 		if isSCardError(err) {
 			return restartErr()
@@ -429,18 +457,8 @@ func (f *KeycardFlow) loadKeys(kc *keycardContext) error {
 		return nil
 	}
 
-	tmpMnemonic, ok := f.params[MnemonicLen]
-	var mnemonicLength int
-	if ok {
-		switch t := tmpMnemonic.(type) {
-		case int:
-			mnemonicLength = t
-		case float64:
-			mnemonicLength = int(t)
-		default:
-			mnemonicLength = defMnemoLen
-		}
-	} else {
+	mnemonicLength, ok := f.params.intValue(MnemonicLen)
+	if !ok {
 		mnemonicLength = defMnemoLen
 	}
 
@@ -462,8 +480,8 @@ func (f *KeycardFlow) loadKeys(kc *keycardContext) error {
 }
 
 func (f *KeycardFlow) changePIN(kc *keycardContext) error {
-	if newPIN, ok := f.params[NewPIN]; ok {
-		err := kc.changePin(newPIN.(string))
+	if newPIN, ok := f.params.stringValue(NewPIN); ok {
+		err := kc.changePin(newPIN)
 
 		if isSCardError(err) {
 			return restartErr()
@@ -484,8 +502,8 @@ func (f *KeycardFlow) changePIN(kc *keycardContext) error {
 }
 
 func (f *KeycardFlow) changePUK(kc *keycardContext) error {
-	if newPUK, ok := f.params[NewPUK]; ok {
-		err := kc.changePuk(newPUK.(string))
+	if newPUK, ok := f.params.stringValue(NewPUK); ok {
+		err := kc.changePuk(newPUK)
 
 		if isSCardError(err) {
 			return restartErr()
@@ -506,8 +524,8 @@ func (f *KeycardFlow) changePUK(kc *keycardContext) error {
 }
 
 func (f *KeycardFlow) changePairing(kc *keycardContext) error {
-	if newPairing, ok := f.params[NewPairing]; ok {
-		err := kc.changePairingPassword(newPairing.(string))
+	if newPairing, ok := f.params.stringValue(NewPairing); ok {
+		err := kc.changePairingPassword(newPairing)
 
 		if isSCardError(err) {
 			return restartErr()
@@ -530,7 +548,8 @@ func (f *KeycardFlow) changePairing(kc *keycardContext) error {
 func (f *KeycardFlow) sign(kc *keycardContext) (*Signature, error) {
 	var err error
 
-	path, pathOK := f.params[BIP44Path]
+	// sessions also accept lists of paths, which cannot be signed with
+	path, pathOK := f.params.stringValue(BIP44Path)
 
 	if !pathOK {
 		err = f.pauseAndWait(EnterPath, ErrorSigning)
@@ -541,12 +560,12 @@ func (f *KeycardFlow) sign(kc *keycardContext) (*Signature, error) {
 		return f.sign(kc)
 	}
 
-	hash, hashOK := f.params[TXHash]
+	hash, hashOK := f.params.stringValue(TXHash)
 
 	var rawHash []byte
 
 	if hashOK {
-		rawHash, err = xtob(hash.(string))
+		rawHash, err = xtob(hash)
 		if err != nil {
 			hashOK = false
 		}
@@ -561,7 +580,214 @@ func (f *KeycardFlow) sign(kc *keycardContext) (*Signature, error) {
 		return f.sign(kc)
 	}
 
-	signature, err := kc.signWithPath(rawHash, path.(string))
+	signature, err := kc.signWithPath(rawHash, path)
+
+	if isSCardError(err) {
+		return nil, restartErr()
+	} else if err != nil {
+		return nil, err
+	}
+
+	return f.toChainSignature(rawHash, signature), nil
+}
+
+// toChainSignature is toSignature with EIP155V set if the ChainID parameter
+// is.
+func (f *KeycardFlow) toChainSignature(hash []byte, signature *ktypes.Signature) *Signature {
+	sig := toSignature(hash, signature)
+
+	if chainID := f.chainIDParam(); chainID != nil {
+		sig.EIP155V = sig.ChainV(chainID)
+	}
+
+	return sig
+}
+
+func (f *KeycardFlow) chainIDParam() *big.Int {
+	if id, ok := f.params.intValue(ChainID); ok {
+		return big.NewInt(int64(id))
+	}
+
+	return nil
+}
+
+func (f *KeycardFlow) signTransaction(kc *keycardContext) (FlowStatus, error) {
+	path, pathOK := f.params.stringValue(BIP44Path)
+
+	if !pathOK {
+		err := f.pauseAndWait(EnterPath, ErrorSigning)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.signTransaction(kc)
+	}
+
+	chainID := f.chainIDParam()
+	txParam, txOK := f.params[Transaction]
+	status := FlowParams{}
+
+	if txOK {
+		tx, txChainID, err := parseTransaction(txParam, chainID)
+
+		if err == nil {
+			return f.signParsedTransaction(kc, tx, txChainID, path)
+		}
+
+		delete(f.params, Transaction)
+		status[ErrorInfo] = newFlowError(ErrorInvalidParam, err)
+	}
+
+	err := f.pauseAndWaitWithStatus(EnterTransaction, ErrorSigning, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.signTransaction(kc)
+}
+
+func (f *KeycardFlow) signParsedTransaction(kc *keycardContext, tx *types.Transaction, chainID *big.Int, path string) (FlowStatus, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	hash := signer.Hash(tx)
+
+	signature, err := kc.signWithPath(hash[:], path)
+
+	if isSCardError(err) {
+		return nil, restartErr()
+	} else if err != nil {
+		return nil, err
+	}
+
+	sig := toSignature(hash[:], signature)
+
+	if tx.Type() == types.LegacyTxType {
+		sig.EIP155V = sig.ChainV(chainID)
+	}
+
+	signed, err := tx.WithSignature(signer, sig.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, err
+	}
+
+	return FlowStatus{
+		KeyUID:      f.cardInfo.keyUID,
+		InstanceUID: f.cardInfo.instanceUID,
+		TXSignature: sig,
+		SignedTX:    hexutil.Encode(raw),
+		TXHashOut:   signed.Hash().Hex(),
+		Sender:      sender.Hex(),
+	}, nil
+}
+
+func (f *KeycardFlow) signTypedData(kc *keycardContext) (FlowStatus, error) {
+	path, pathOK := f.params.stringValue(BIP44Path)
+
+	if !pathOK {
+		err := f.pauseAndWait(EnterPath, ErrorSigning)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.signTypedData(kc)
+	}
+
+	status := FlowParams{}
+
+	if typedData, ok := f.params[TypedData]; ok {
+		td, err := parseTypedData(typedData)
+
+		var hashes *typedDataHashes
+		if err == nil {
+			hashes, err = hashTypedData(td)
+		}
+
+		if err == nil {
+			signature, err := kc.signWithPath(hashes.hash, path)
+
+			if isSCardError(err) {
+				return nil, restartErr()
+			} else if err != nil {
+				return nil, err
+			}
+
+			sig := toSignature(hashes.hash, signature)
+
+			return FlowStatus{
+				KeyUID:       f.cardInfo.keyUID,
+				InstanceUID:  f.cardInfo.instanceUID,
+				TXSignature:  sig,
+				RawSignature: ethSignature(sig),
+				DomainHash:   hexutil.Encode(hashes.domain),
+				MessageHash:  hexutil.Encode(hashes.message),
+				SignedHash:   hexutil.Encode(hashes.hash),
+			}, nil
+		}
+
+		delete(f.params, TypedData)
+		status[ErrorInfo] = newFlowError(ErrorInvalidParam, err)
+	}
+
+	err := f.pauseAndWaitWithStatus(EnterTypedData, ErrorSigning, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.signTypedData(kc)
+}
+
+func (f *KeycardFlow) signMessage(kc *keycardContext) (FlowStatus, error) {
+	path, pathOK := f.params.stringValue(BIP44Path)
+
+	if !pathOK {
+		err := f.pauseAndWait(EnterPath, ErrorSigning)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.signMessage(kc)
+	}
+
+	var message []byte
+	var err error
+	messageOK := true
+
+	if text, ok := f.params.stringValue(Message); ok {
+		message = []byte(text)
+	} else if hexMessage, ok := f.params.stringValue(MessageHex); ok {
+		message, err = xtob(strings.TrimPrefix(hexMessage, "0x"))
+		messageOK = err == nil
+	} else {
+		messageOK = false
+	}
+
+	if !messageOK {
+		status := FlowParams{}
+
+		if err != nil {
+			delete(f.params, MessageHex)
+			status[ErrorInfo] = newFlowError(ErrorInvalidParam, err)
+		}
+
+		err := f.pauseAndWaitWithStatus(EnterMessage, ErrorSigning, status)
+		if err != nil {
+			return nil, err
+		}
+
+		return f.signMessage(kc)
+	}
+
+	hash := textHash(message)
+	signature, err := kc.signWithPath(hash, path)
 
 	if isSCardError(err) {
 		return nil, restartErr()
@@ -569,5 +795,34 @@ func (f *KeycardFlow) sign(kc *keycardContext) (*Signature, error) {
 		return nil, err
 	}
 
-	return toSignature(signature), nil
+	sig := toSignature(hash, signature)
+
+	err = verifySigner(sig, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return FlowStatus{
+		KeyUID:       f.cardInfo.keyUID,
+		InstanceUID:  f.cardInfo.instanceUID,
+		TXSignature:  sig,
+		RawSignature: ethSignature(sig),
+		SignedHash:   hexutil.Encode(hash),
+		Address:      sig.Address,
+	}, nil
+}
+
+// verifySigner checks that the signer recovered from the signature is the key
+// the card signed with, as returned along with the signature, rather than
+// exporting the key of the path once more.
+func verifySigner(signature *Signature, cardSig *ktypes.Signature) error {
+	if signature.Address == "" {
+		return newFlowError(ErrorBadResponse, errors.New("cannot recover the signer"))
+	}
+
+	if !bytes.Equal(signature.PublicKey, cardSig.PubKey()) {
+		return newFlowError(ErrorBadResponse, errors.New("signature does not match the key of the card"))
+	}
+
+	return nil
 }
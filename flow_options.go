@@ -0,0 +1,20 @@
+package statuskeycardgo
+
+// FlowOption configures a KeycardFlow at creation.
+type FlowOption func(*KeycardFlow)
+
+// WithReaderAllowlist restricts the flow to readers matching at least one of
+// the given names or glob patterns.
+func WithReaderAllowlist(patterns ...string) FlowOption {
+	return func(f *KeycardFlow) {
+		f.readerFilter.allow = patterns
+	}
+}
+
+// WithReaderDenylist excludes the readers matching any of the given names or
+// glob patterns. It takes precedence over the allowlist.
+func WithReaderDenylist(patterns ...string) FlowOption {
+	return func(f *KeycardFlow) {
+		f.readerFilter.deny = patterns
+	}
+}
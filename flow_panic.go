@@ -0,0 +1,37 @@
+package statuskeycardgo
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// flowPanic is a panic recovered in one of the flow goroutines, along with the
+// stack where it happened. Panics of the card worker thread are handed over to
+// the flow goroutine as *flowPanic, so that the original stack is kept.
+type flowPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// recoveredPanic wraps the value returned by recover. It must be called from
+// the deferred function to capture the stack of the panic.
+func recoveredPanic(r interface{}) *flowPanic {
+	if p, ok := r.(*flowPanic); ok {
+		return p
+	}
+
+	return &flowPanic{value: r, stack: debug.Stack()}
+}
+
+func (p *flowPanic) Error() string {
+	return fmt.Sprintf("%s: %v", ErrorInternal, p.value)
+}
+
+// status is the result of the flow, the stack is only logged: it is of no use
+// to the UI and could leak parameters.
+func (p *flowPanic) status() FlowStatus {
+	return FlowStatus{
+		ErrorKey:  ErrorInternal,
+		ErrorInfo: toFlowError(p),
+	}
+}
@@ -0,0 +1,62 @@
+package statuskeycardgo
+
+import (
+	"testing"
+)
+
+// panickingTransport connects to cards which panic on every APDU.
+type panickingTransport struct {
+	Transport
+}
+
+type panickingCard struct {
+	Card
+}
+
+func (t *panickingTransport) Connect(reader string) (Card, error) {
+	card, err := t.Transport.Connect(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &panickingCard{card}, nil
+}
+
+func (c *panickingCard) Transmit(apdu []byte) ([]byte, error) {
+	panic("card exploded")
+}
+
+func TestFlowPanic(t *testing.T) {
+	signals := recordSignals(t)
+	emu, _ := newTestEmulator(t)
+
+	f := newTestFlowWithTransport(t, func() (Transport, error) {
+		inner, err := emu.NewTransport()
+		if err != nil {
+			return nil, err
+		}
+
+		return &panickingTransport{inner}, nil
+	})
+
+	if err := f.Start(GetAppInfo, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := waitForSignal(t, signals, FlowResult)
+
+	if result[ErrorKey] != ErrorInternal {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if _, ok := result["stack-trace"]; ok {
+		t.Fatal("stack trace sent with the result")
+	}
+
+	info, ok := result[ErrorInfo].(map[string]interface{})
+	if !ok || info["code"] != ErrorInternal || info["detail"] != "card exploded" {
+		t.Fatalf("unexpected error info %+v", result[ErrorInfo])
+	}
+
+	waitForIdle(t, f.GetState, f.Cancel)
+}
@@ -0,0 +1,391 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+type paramType int
+
+const (
+	stringParam paramType = iota
+	boolParam
+	intParam
+	stringListParam
+	pathsParam
+	intMapParam
+	objectParam
+	objectListParam
+)
+
+var paramTypeNames = map[paramType]string{
+	stringParam:     "string",
+	boolParam:       "bool",
+	intParam:        "integer",
+	stringListParam: "string-list",
+	pathsParam:      "string-or-string-list",
+	intMapParam:     "integer-map",
+	objectParam:     "string-or-object",
+	objectListParam: "object-list",
+}
+
+func paramTypeByName(name string) (paramType, bool) {
+	for t, n := range paramTypeNames {
+		if n == name {
+			return t, true
+		}
+	}
+
+	return 0, false
+}
+
+// commonParams are accepted by all flows.
+var commonParams = map[string]paramType{
+	InstanceUID:  stringParam,
+	KeyUID:       stringParam,
+	ReaderName:   stringParam,
+	FactoryReset: boolParam,
+	Overwrite:    boolParam,
+	PairingPass:  stringParam,
+	PIN:          stringParam,
+	PUK:          stringParam,
+	NewPIN:       stringParam,
+	NewPUK:       stringParam,
+	NewPairing:   stringParam,
+	FlowTimeout:  intParam,
+	PauseTimeout: intParam,
+	ActTimeouts:  intMapParam,
+	HeartbeatInt: intParam,
+}
+
+var flowParams = map[FlowType]map[string]paramType{
+	LoadAccount: {
+		Mnemonic:    stringParam,
+		MnemonicLen: intParam,
+	},
+	ExportPublic: {
+		BIP44Path:    pathsParam,
+		ExportMaster: boolParam,
+		ExportPriv:   boolParam,
+	},
+	Sign: {
+		BIP44Path: stringParam,
+		TXHash:    stringParam,
+		ChainID:   intParam,
+	},
+	StoreMetadata: {
+		CardName:    stringParam,
+		WalletPaths: stringListParam,
+	},
+	GetMetadata: {
+		ResolveAddr:  boolParam,
+		ExportMaster: boolParam,
+	},
+	SignTransaction: {
+		BIP44Path:   stringParam,
+		Transaction: objectParam,
+		ChainID:     intParam,
+	},
+	SignTypedData: {
+		BIP44Path: stringParam,
+		TypedData: objectParam,
+	},
+	SignMessage: {
+		BIP44Path:  stringParam,
+		Message:    stringParam,
+		MessageHex: stringParam,
+	},
+	SignBatch: {
+		BIP44Path: stringParam,
+		SignItems: objectListParam,
+		ChainID:   intParam,
+	},
+	Session: {
+		Operation:    stringParam,
+		BIP44Path:    pathsParam,
+		ExportMaster: boolParam,
+		ExportPriv:   boolParam,
+		TXHash:       stringParam,
+		ChainID:      intParam,
+		CardName:     stringParam,
+		WalletPaths:  stringListParam,
+	},
+}
+
+// ParamError reports a parameter whose value does not have the type expected
+// by the flow.
+type ParamError struct {
+	Key      string
+	Expected string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("%s: %s must be %s", ErrorInvalidParam, e.Key, e.Expected)
+}
+
+// MarshalJSON encodes the error in the form returned by the C API.
+func (e *ParamError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		ErrorKey:   ErrorInvalidParam,
+		"key":      e.Key,
+		"expected": e.Expected,
+	})
+}
+
+// DecodeFlowParams decodes parameters given as JSON, like the C API does.
+// Numbers are decoded as json.Number rather than float64, so that large
+// integers, like the uint256 values of typed data, keep their precision.
+func DecodeFlowParams(data []byte) (FlowParams, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var params FlowParams
+
+	if err := d.Decode(&params); err != nil {
+		return nil, err
+	}
+
+	if d.More() {
+		return nil, errors.New("unexpected data after the parameters")
+	}
+
+	return params, nil
+}
+
+// validateParams checks the parameters against the schema of the flow, built-in
+// or declared with RegisterFlowParams. Keys outside of the schema are ignored.
+// Null values are removed, string lists normalized to []interface{} and
+// integer maps to map[string]interface{}, as decoded from JSON, so that the
+// accessors below can read any valid parameter.
+func validateParams(flowType FlowType, params FlowParams) error {
+	keys := make([]string, 0, len(params))
+
+	for k := range params {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	schema, ok := flowParams[flowType]
+
+	if !ok {
+		schema = registeredParams(flowType)
+	}
+
+	for _, k := range keys {
+		t, ok := commonParams[k]
+
+		if !ok {
+			t, ok = schema[k]
+		}
+
+		if !ok {
+			continue
+		}
+
+		if params[k] == nil {
+			delete(params, k)
+			continue
+		}
+
+		v, valid := checkParam(t, params[k])
+
+		if !valid {
+			return &ParamError{Key: k, Expected: paramTypeNames[t]}
+		}
+
+		params[k] = v
+	}
+
+	return nil
+}
+
+func checkParam(t paramType, v interface{}) (interface{}, bool) {
+	switch t {
+	case stringParam:
+		_, ok := v.(string)
+		return v, ok
+	case boolParam:
+		_, ok := v.(bool)
+		return v, ok
+	case intParam:
+		switch n := v.(type) {
+		case int:
+			return v, true
+		case float64:
+			return v, n == math.Trunc(n)
+		case json.Number:
+			i, err := n.Int64()
+			return int(i), err == nil
+		}
+
+		return v, false
+	case pathsParam:
+		if _, ok := v.(string); ok {
+			return v, true
+		}
+
+		return checkParam(stringListParam, v)
+	case intMapParam:
+		if m, ok := v.(map[string]int); ok {
+			items := make(map[string]interface{}, len(m))
+
+			for k := range m {
+				items[k] = m[k]
+			}
+
+			return items, true
+		}
+
+		m, ok := v.(map[string]interface{})
+
+		if !ok {
+			return v, false
+		}
+
+		items := make(map[string]interface{}, len(m))
+
+		for k := range m {
+			item, ok := checkParam(intParam, m[k])
+
+			if !ok {
+				return v, false
+			}
+
+			items[k] = item
+		}
+
+		return items, true
+	case objectParam:
+		switch v.(type) {
+		case string, map[string]interface{}:
+			return v, true
+		}
+
+		return v, false
+	case objectListParam:
+		if list, ok := v.([]map[string]interface{}); ok {
+			items := make([]interface{}, len(list))
+
+			for i := range list {
+				items[i] = list[i]
+			}
+
+			return items, true
+		}
+
+		items, ok := v.([]interface{})
+
+		if !ok {
+			return v, false
+		}
+
+		for _, item := range items {
+			if _, ok := item.(map[string]interface{}); !ok {
+				return v, false
+			}
+		}
+
+		return v, true
+	case stringListParam:
+		if list, ok := v.([]string); ok {
+			items := make([]interface{}, len(list))
+
+			for i := range list {
+				items[i] = list[i]
+			}
+
+			return items, true
+		}
+
+		items, ok := v.([]interface{})
+
+		if !ok {
+			return v, false
+		}
+
+		for _, item := range items {
+			if _, ok := item.(string); !ok {
+				return v, false
+			}
+		}
+
+		return v, true
+	}
+
+	return v, false
+}
+
+// The accessors below read parameters checked by validateParams. A missing
+// parameter reads as absent, and so does one of another type, which can only
+// come from a key outside of the schema of the flow.
+
+func (p FlowParams) stringValue(key string) (string, bool) {
+	s, ok := p[key].(string)
+	return s, ok
+}
+
+func (p FlowParams) boolValue(key string) bool {
+	b, _ := p[key].(bool)
+	return b
+}
+
+// intValue also accepts integral float64 values and json.Number, as decoded
+// from JSON.
+func (p FlowParams) intValue(key string) (int, bool) {
+	switch n := p[key].(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), n == math.Trunc(n)
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err == nil
+	}
+
+	return 0, false
+}
+
+func (p FlowParams) stringList(key string) ([]string, bool) {
+	items, ok := p[key].([]interface{})
+
+	if !ok {
+		return nil, false
+	}
+
+	list := make([]string, len(items))
+
+	for i, item := range items {
+		if list[i], ok = item.(string); !ok {
+			return nil, false
+		}
+	}
+
+	return list, true
+}
+
+func (p FlowParams) objectList(key string) ([]FlowParams, bool) {
+	items, ok := p[key].([]interface{})
+
+	if !ok {
+		return nil, false
+	}
+
+	list := make([]FlowParams, len(items))
+
+	for i, item := range items {
+		object, ok := item.(map[string]interface{})
+
+		if !ok {
+			return nil, false
+		}
+
+		list[i] = object
+	}
+
+	return list, true
+}
@@ -0,0 +1,208 @@
+package statuskeycardgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		flowType FlowType
+		params   FlowParams
+		expected FlowParams
+		invalid  *ParamError
+	}{
+		{
+			name:     "common",
+			flowType: ChangePIN,
+			params:   FlowParams{PIN: testPIN, FactoryReset: false, FlowTimeout: float64(30), PauseTimeout: 10},
+			expected: FlowParams{PIN: testPIN, FactoryReset: false, FlowTimeout: float64(30), PauseTimeout: 10},
+		},
+		{
+			name:     "null removed",
+			flowType: ChangePIN,
+			params:   FlowParams{PIN: nil, NewPIN: "654321"},
+			expected: FlowParams{NewPIN: "654321"},
+		},
+		{
+			name:     "outside schema ignored",
+			flowType: ChangePIN,
+			params:   FlowParams{Mnemonic: 12, "unknown": true},
+			expected: FlowParams{Mnemonic: 12, "unknown": true},
+		},
+		{
+			name:     "string instead of bool",
+			flowType: LoadAccount,
+			params:   FlowParams{Overwrite: "true"},
+			invalid:  &ParamError{Key: Overwrite, Expected: "bool"},
+		},
+		{
+			name:     "number instead of string",
+			flowType: Login,
+			params:   FlowParams{PIN: float64(123456)},
+			invalid:  &ParamError{Key: PIN, Expected: "string"},
+		},
+		{
+			name:     "fractional integer",
+			flowType: LoadAccount,
+			params:   FlowParams{MnemonicLen: 12.5},
+			invalid:  &ParamError{Key: MnemonicLen, Expected: "integer"},
+		},
+		{
+			name:     "first invalid key",
+			flowType: Login,
+			params:   FlowParams{PUK: 1, PIN: 1},
+			invalid:  &ParamError{Key: PIN, Expected: "string"},
+		},
+		{
+			name:     "integer map",
+			flowType: Login,
+			params:   FlowParams{ActTimeouts: map[string]int{EnterPIN: 10}},
+			expected: FlowParams{ActTimeouts: map[string]interface{}{EnterPIN: 10}},
+		},
+		{
+			name:     "invalid integer map",
+			flowType: Login,
+			params:   FlowParams{ActTimeouts: map[string]interface{}{EnterPIN: "10"}},
+			invalid:  &ParamError{Key: ActTimeouts, Expected: "integer-map"},
+		},
+		{
+			name:     "single path",
+			flowType: ExportPublic,
+			params:   FlowParams{BIP44Path: "m/44'/60'/0'/0/0"},
+			expected: FlowParams{BIP44Path: "m/44'/60'/0'/0/0"},
+		},
+		{
+			name:     "path list",
+			flowType: ExportPublic,
+			params:   FlowParams{BIP44Path: []string{"m/44'/60'/0'/0/0"}},
+			expected: FlowParams{BIP44Path: []interface{}{"m/44'/60'/0'/0/0"}},
+		},
+		{
+			name:     "invalid path list",
+			flowType: ExportPublic,
+			params:   FlowParams{BIP44Path: []interface{}{"m/44'/60'/0'/0/0", 1}},
+			invalid:  &ParamError{Key: BIP44Path, Expected: "string-or-string-list"},
+		},
+		{
+			name:     "path list of sign",
+			flowType: Sign,
+			params:   FlowParams{BIP44Path: []interface{}{"m/44'/60'/0'/0/0"}},
+			invalid:  &ParamError{Key: BIP44Path, Expected: "string"},
+		},
+		{
+			name:     "object",
+			flowType: SignTypedData,
+			params:   FlowParams{TypedData: map[string]interface{}{"primaryType": "Mail"}},
+			expected: FlowParams{TypedData: map[string]interface{}{"primaryType": "Mail"}},
+		},
+		{
+			name:     "invalid object",
+			flowType: SignTransaction,
+			params:   FlowParams{Transaction: float64(1)},
+			invalid:  &ParamError{Key: Transaction, Expected: "string-or-object"},
+		},
+		{
+			name:     "object list",
+			flowType: SignBatch,
+			params:   FlowParams{SignItems: []map[string]interface{}{{TXHash: testHash}}},
+			expected: FlowParams{SignItems: []interface{}{map[string]interface{}{TXHash: testHash}}},
+		},
+		{
+			name:     "invalid object list",
+			flowType: SignBatch,
+			params:   FlowParams{SignItems: []interface{}{testHash}},
+			invalid:  &ParamError{Key: SignItems, Expected: "object-list"},
+		},
+	}
+
+	for _, test := range tests {
+		err := validateParams(test.flowType, test.params)
+
+		if test.invalid != nil {
+			if !reflect.DeepEqual(err, test.invalid) {
+				t.Errorf("%s: expected %v, got %v", test.name, test.invalid, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if !reflect.DeepEqual(test.params, test.expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, test.params)
+		}
+	}
+}
+
+func TestParamErrorJSON(t *testing.T) {
+	data, err := json.Marshal(&ParamError{Key: PIN, Expected: "string"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"error":"invalid-param","expected":"string","key":"pin"}` {
+		t.Fatalf("unexpected JSON %s", data)
+	}
+}
+
+func TestFlowRejectsInvalidParams(t *testing.T) {
+	signals := recordSignals(t)
+	f := newTestFlow(t)
+
+	if _, ok := f.Start(ChangePIN, FlowParams{PIN: float64(123456)}).(*ParamError); !ok {
+		t.Fatal("started with an invalid PIN")
+	}
+
+	if state := f.GetState(); state.State != Idle.String() {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterPIN)
+
+	if _, ok := f.Resume(FlowParams{PIN: float64(123456)}).(*ParamError); !ok {
+		t.Fatal("resumed with an invalid PIN")
+	}
+
+	if state := f.GetState(); state.State != Paused.String() || state.PendingAction != EnterPIN {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	if err := f.Resume(FlowParams{PIN: testPIN}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterNewPIN)
+	waitForIdle(t, f.GetState, f.Cancel)
+}
+
+func TestDecodeFlowParams(t *testing.T) {
+	params, err := DecodeFlowParams([]byte(`{"pin": "123456", "chain-id": 1, "action-timeouts": {"keycard.action.enter-pin": 10}, "mnemonic-length": 12.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if params[ChainID] != json.Number("1") {
+		t.Fatalf("unexpected params %+v", params)
+	}
+
+	if err := validateParams(SignTransaction, params); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := FlowParams{PIN: testPIN, ChainID: 1, ActTimeouts: map[string]interface{}{EnterPIN: 10}, MnemonicLen: json.Number("12.5")}
+
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, params)
+	}
+
+	if _, ok := validateParams(LoadAccount, params).(*ParamError); !ok {
+		t.Fatal("validated a fractional mnemonic length")
+	}
+
+	if _, err := DecodeFlowParams([]byte(`{"pin": "123456"} {}`)); err == nil {
+		t.Fatal("decoded trailing data")
+	}
+}
@@ -0,0 +1,164 @@
+package statuskeycardgo
+
+import (
+	"errors"
+	"time"
+)
+
+// persistentSession is the connection of a flow kept open once it ended, with
+// the secure channel opened and the PIN verified, so that the next flow can
+// skip openSCAndAuthenticate.
+type persistentSession struct {
+	kc       *keycardContext
+	cardInfo cardStatus
+	taken    chan (struct{})
+}
+
+// sessionFlows are the flows which can reuse a persistent session, since they
+// only sign. The other flows change credentials or pairings, load keys or
+// export private keys: they close the session and verify the PIN again.
+var sessionFlows = map[FlowType]bool{
+	Sign:            true,
+	SignTransaction: true,
+	SignTypedData:   true,
+	SignMessage:     true,
+	SignBatch:       true,
+}
+
+// WithPersistentSession keeps the card connected and authenticated for idle
+// after a flow authenticated successfully. The following signing flows reuse
+// the session instead of opening a secure channel and verifying the PIN again,
+// unless their parameters require another card or reader, any other flow
+// closes it. The session is also closed once idle expires, the card is
+// removed, the pairing is removed or ClosePersistentSession is called.
+func WithPersistentSession(idle time.Duration) FlowOption {
+	return func(f *KeycardFlow) {
+		f.sessionIdle = idle
+	}
+}
+
+// ClosePersistentSession closes the session kept by WithPersistentSession,
+// for instance when the user locks the application. It fails if no session is
+// kept, the session of a running flow is closed when the flow ends.
+func (f *KeycardFlow) ClosePersistentSession() error {
+	s := f.takeSession()
+
+	if s == nil {
+		return errors.New("no persistent session")
+	}
+
+	s.kc.stop()
+
+	return nil
+}
+
+// keepSession keeps kc open once the flow ended, if the flow authenticated and
+// persistent sessions are enabled.
+func (f *KeycardFlow) keepSession(kc *keycardContext) bool {
+	if f.sessionIdle <= 0 || !kc.authenticated {
+		return false
+	}
+
+	s := &persistentSession{kc: kc, cardInfo: f.cardInfo, taken: make(chan (struct{}))}
+
+	f.mu.Lock()
+	f.session = s
+	f.mu.Unlock()
+
+	go f.watchSession(s)
+
+	return true
+}
+
+// watchSession closes the session once it has been idle for too long, or the
+// card has been removed, unless a flow took it meanwhile.
+func (f *KeycardFlow) watchSession(s *persistentSession) {
+	t := time.NewTimer(f.sessionIdle)
+	defer t.Stop()
+
+	select {
+	case <-s.taken:
+		return
+	case <-s.kc.removed:
+		l("persistent session closed, card removed")
+	case <-t.C:
+		l("persistent session closed, idle timeout")
+	}
+
+	f.mu.Lock()
+
+	if f.session != s {
+		f.mu.Unlock()
+		return
+	}
+
+	f.session = nil
+	f.mu.Unlock()
+
+	s.kc.stop()
+}
+
+func (f *KeycardFlow) takeSession() *persistentSession {
+	f.mu.Lock()
+	s := f.session
+	f.session = nil
+	f.mu.Unlock()
+
+	if s != nil {
+		close(s.taken)
+	}
+
+	return s
+}
+
+// resumeSession returns the connection of the persistent session if it can be
+// used by the flow, with the card info of the flow restored. Sessions which
+// cannot be used are closed.
+func (f *KeycardFlow) resumeSession() *keycardContext {
+	s := f.takeSession()
+
+	if s == nil {
+		return nil
+	}
+
+	if !f.sessionUsable(s) {
+		s.kc.stop()
+		return nil
+	}
+
+	f.cardInfo = s.cardInfo
+	f.cardRemoved = s.kc.removed
+	f.setReader(s.kc.reader)
+
+	return s.kc
+}
+
+func (f *KeycardFlow) sessionUsable(s *persistentSession) bool {
+	if !sessionFlows[f.flowType] {
+		return false
+	}
+
+	select {
+	case <-s.kc.removed:
+		return false
+	default:
+	}
+
+	if f.params.boolValue(FactoryReset) {
+		return false
+	}
+
+	if reader, ok := f.params.stringValue(ReaderName); ok && !matchReader(reader, s.kc.reader) {
+		return false
+	}
+
+	if instanceUID, ok := f.params.stringValue(InstanceUID); ok && instanceUID != s.cardInfo.instanceUID {
+		return false
+	}
+
+	if keyUID, ok := f.params.stringValue(KeyUID); ok && keyUID != s.cardInfo.keyUID {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,116 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testHash = "50b2c43fd39106bafbba0da34fc430e1f91e3c96ea2acee2bc34119f92b37750"
+
+// refusingPrompter records the prompted actions and cancels the flow.
+type refusingPrompter struct {
+	prompted []string
+}
+
+func (p *refusingPrompter) Prompt(ctx context.Context, action string, status FlowStatus) (FlowParams, error) {
+	p.prompted = append(p.prompted, action)
+	return nil, errors.New("refused")
+}
+
+func (p *refusingPrompter) Notify(action string, status FlowStatus) {}
+
+func signParams(pin string) FlowParams {
+	params := FlowParams{BIP44Path: "m/44'/60'/0'/0/0", TXHash: testHash}
+
+	if pin != "" {
+		params[PIN] = pin
+	}
+
+	return params
+}
+
+func TestPersistentSessionReusedBySigningFlows(t *testing.T) {
+	f := newTestFlow(t, WithPersistentSession(time.Minute))
+	ctx := context.Background()
+
+	_, err := f.RunFlow(ctx, LoadAccount, FlowParams{PIN: testPIN, Mnemonic: testMnemonic}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		p := &refusingPrompter{}
+
+		result, err := f.RunFlow(ctx, Sign, signParams(""), p)
+		if err != nil || result[TXSignature] == nil {
+			t.Fatalf("sign failed: %+v %v, prompted %v", result, err, p.prompted)
+		}
+	}
+}
+
+func TestPersistentSessionClosedByOtherFlows(t *testing.T) {
+	f := newTestFlow(t, WithPersistentSession(time.Minute))
+	ctx := context.Background()
+
+	_, err := f.RunFlow(ctx, LoadAccount, FlowParams{PIN: testPIN, Mnemonic: testMnemonic}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flows := []struct {
+		flowType FlowType
+		params   FlowParams
+	}{
+		{ChangePIN, FlowParams{NewPIN: "654321"}},
+		{Login, FlowParams{}},
+		{LoadAccount, FlowParams{Mnemonic: testMnemonic, Overwrite: true}},
+	}
+
+	for _, flow := range flows {
+		if _, err := f.RunFlow(ctx, Sign, signParams(testPIN), &refusingPrompter{}); err != nil {
+			t.Fatal(err)
+		}
+
+		p := &refusingPrompter{}
+
+		if _, err := f.RunFlow(ctx, flow.flowType, flow.params, p); err == nil {
+			t.Fatalf("flow %d ran without PIN", flow.flowType)
+		}
+
+		if len(p.prompted) == 0 || p.prompted[0] != EnterPIN {
+			t.Fatalf("flow %d prompted %v instead of the PIN", flow.flowType, p.prompted)
+		}
+	}
+}
+
+func TestPersistentSessionReaderPattern(t *testing.T) {
+	f := newTestFlow(t, WithPersistentSession(time.Minute))
+	ctx := context.Background()
+
+	_, err := f.RunFlow(ctx, LoadAccount, FlowParams{PIN: testPIN, Mnemonic: testMnemonic}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := signParams("")
+	params[ReaderName] = "rea*"
+	p := &refusingPrompter{}
+
+	result, err := f.RunFlow(ctx, Sign, params, p)
+	if err != nil || result[TXSignature] == nil {
+		t.Fatalf("sign failed: %+v %v, prompted %v", result, err, p.prompted)
+	}
+
+	params[ReaderName] = "other*"
+
+	result, err = f.RunFlow(ctx, Sign, params, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result[ErrorKey] != ErrorNoReader {
+		t.Fatalf("signed with the session of another reader %+v", result)
+	}
+}
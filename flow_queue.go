@@ -0,0 +1,114 @@
+package statuskeycardgo
+
+import (
+	"errors"
+)
+
+type queuedFlow struct {
+	requestID string
+	flowType  FlowType
+	params    FlowParams
+}
+
+// Enqueue runs the flow once the flows started or enqueued before have ended,
+// instead of failing like Start while a flow is running. The parameters are
+// validated right away. The FlowResult of the flow carries requestID under
+// RequestID, which must be unique among the running and queued flows.
+func (f *KeycardFlow) Enqueue(requestID string, flowType FlowType, params FlowParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if requestID == "" {
+		return errors.New("missing request id")
+	}
+
+	if f.hasRequest(requestID) {
+		return errors.New("duplicate request id")
+	}
+
+	if params == nil {
+		params = FlowParams{}
+	}
+
+	if err := validateParams(flowType, params); err != nil {
+		return err
+	}
+
+	f.queue = append(f.queue, queuedFlow{requestID, flowType, params})
+
+	if f.state == Idle {
+		f.runQueued()
+	}
+
+	return nil
+}
+
+// CancelRequest removes an enqueued flow from the queue, or cancels it if it
+// is running. Like with Cancel, no FlowResult is signalled.
+func (f *KeycardFlow) CancelRequest(requestID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if requestID != "" && requestID == f.requestID && f.state != Idle {
+		f.cancel()
+		return nil
+	}
+
+	for i, q := range f.queue {
+		if q.requestID == requestID {
+			f.queue = append(f.queue[:i], f.queue[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("unknown request id")
+}
+
+func (f *KeycardFlow) hasRequest(requestID string) bool {
+	if requestID == f.requestID && f.state != Idle {
+		return true
+	}
+
+	for _, q := range f.queue {
+		if q.requestID == requestID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runQueued must be called with mu held and the flow Idle.
+func (f *KeycardFlow) runQueued() {
+	if f.dequeue() {
+		go f.runFlow()
+	}
+}
+
+// dequeue prepares the next enqueued flow, if any, the caller starts runFlow.
+// It must be called with mu held and the flow Idle.
+func (f *KeycardFlow) dequeue() bool {
+	if len(f.queue) == 0 {
+		return false
+	}
+
+	q := f.queue[0]
+	f.queue = f.queue[1:]
+	f.prepare(q.flowType, q.params, nil, q.requestID)
+
+	return true
+}
+
+func (f *KeycardFlow) queuedRequests() []string {
+	if len(f.queue) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(f.queue))
+
+	for i, q := range f.queue {
+		ids[i] = q.requestID
+	}
+
+	return ids
+}
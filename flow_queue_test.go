@@ -0,0 +1,165 @@
+package statuskeycardgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/status-im/status-keycard-go/signal"
+)
+
+func TestEnqueue(t *testing.T) {
+	signals := recordSignals(t)
+	f := newTestFlow(t)
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterPIN)
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := f.Enqueue(id, GetAppInfo, FlowParams{PIN: testPIN}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := f.Enqueue("", GetAppInfo, nil); err == nil {
+		t.Fatal("enqueued without request id")
+	}
+
+	if err := f.Enqueue("a", GetAppInfo, nil); err == nil {
+		t.Fatal("enqueued a duplicate request id")
+	}
+
+	if _, ok := f.Enqueue("d", GetAppInfo, FlowParams{PIN: 1}).(*ParamError); !ok {
+		t.Fatal("enqueued an invalid PIN")
+	}
+
+	if err := f.CancelRequest("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.CancelRequest("b"); err == nil {
+		t.Fatal("cancelled a request twice")
+	}
+
+	if state := f.GetState(); !reflect.DeepEqual(state.Queue, []string{"a", "c"}) || state.RequestID != "" {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	// the cancelled flow has no result, the queue carries on
+	if err := f.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"a", "c"} {
+		result := waitForSignal(t, signals, FlowResult)
+
+		if result[RequestID] != id || result[ErrorKey] != ErrorOK {
+			t.Fatalf("expected the result of %s, got %+v", id, result)
+		}
+	}
+
+	waitForIdle(t, f.GetState, f.Cancel)
+
+	if state := f.GetState(); state.Queue != nil || state.RequestID != "c" {
+		t.Fatalf("unexpected state %+v", state)
+	}
+}
+
+func TestCancelRunningRequest(t *testing.T) {
+	signals := recordSignals(t)
+	f := newTestFlow(t)
+
+	if err := f.Enqueue("a", ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Enqueue("b", GetAppInfo, FlowParams{PIN: testPIN}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterPIN)
+
+	if state := f.GetState(); state.RequestID != "a" || !reflect.DeepEqual(state.Queue, []string{"b"}) {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	if err := f.CancelRequest("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := waitForSignal(t, signals, FlowResult); result[RequestID] != "b" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if err := f.CancelRequest("a"); err == nil {
+		t.Fatal("cancelled a finished request")
+	}
+}
+
+// startOnResult calls start from the signal handler on each of the first n
+// FlowResult signals, and delivers what it returned to the returned channel.
+func startOnResult(t *testing.T, n int, start func() error) (chan testSignal, chan error) {
+	signals := recordSignals(t)
+	errs := make(chan error, n)
+
+	signal.SetKeycardSignalHandler(func(data []byte) {
+		var s testSignal
+
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Errorf("invalid signal %s: %v", data, err)
+			return
+		}
+
+		if s.Type == FlowResult && n > 0 {
+			n--
+			errs <- start()
+		}
+
+		signals <- s
+	})
+
+	return signals, errs
+}
+
+func TestStartDoesNotOvertakeQueue(t *testing.T) {
+	f := newTestFlow(t)
+	signals, errs := startOnResult(t, 2, func() error { return f.Start(GetAppInfo, FlowParams{PIN: testPIN}) })
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterPIN)
+
+	if err := f.Enqueue("a", GetAppInfo, FlowParams{PIN: testPIN}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Resume(FlowParams{PIN: testPIN, NewPIN: testPIN}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the enqueued flow runs first, the handler starts its flow afterwards
+	if err := <-errs; err == nil {
+		t.Fatal("started ahead of the queue")
+	}
+
+	if result := waitForSignal(t, signals, FlowResult); result[RequestID] != nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if result := waitForSignal(t, signals, FlowResult); result[RequestID] != "a" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	if result := waitForSignal(t, signals, FlowResult); result[RequestID] != nil || result[ErrorKey] != ErrorOK {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
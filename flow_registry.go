@@ -0,0 +1,224 @@
+package statuskeycardgo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CustomFlowBase is the first FlowType available to RegisterFlow, the lower
+// ones are reserved for the flows of this package.
+const CustomFlowBase FlowType = 1000
+
+// FlowHandler runs a registered flow once the card is connected and the applet
+// selected. The status it returns is signalled as FlowResult, errors are
+// reported like those of the built-in flows. Errors returned by the helpers of
+// FlowContext must be returned as is, they restart or end the flow.
+type FlowHandler func(c *FlowContext) (FlowStatus, error)
+
+type registeredFlow struct {
+	name    string
+	handler FlowHandler
+	params  map[string]paramType
+}
+
+var (
+	registryMu      sync.RWMutex
+	registeredFlows = map[FlowType]registeredFlow{}
+)
+
+// RegisterFlow makes flowType available to Start, Enqueue and RunFlow, as well
+// as to the C API. It is meant to be called from init functions, flowType must
+// be at least CustomFlowBase and name is only used by RegisteredFlows.
+func RegisterFlow(flowType FlowType, name string, handler FlowHandler) error {
+	if flowType < CustomFlowBase {
+		return fmt.Errorf("flow type %d is reserved", flowType)
+	}
+
+	if handler == nil {
+		return errors.New("missing flow handler")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registeredFlows[flowType]; ok {
+		return fmt.Errorf("flow type %d already registered", flowType)
+	}
+
+	registeredFlows[flowType] = registeredFlow{name: name, handler: handler}
+
+	return nil
+}
+
+// RegisterFlowParams declares the types of the parameters of a flow added with
+// RegisterFlow, which are then checked on Start, Enqueue, Resume and RunFlow
+// like those of the built-in flows. Types are named like in ParamError:
+// "string", "bool", "integer", "string-list", "string-or-string-list",
+// "integer-map", "string-or-object" and "object-list". The parameters common
+// to all flows, like PIN, are always checked and cannot be redeclared.
+func RegisterFlowParams(flowType FlowType, params map[string]string) error {
+	types := make(map[string]paramType, len(params))
+
+	for key, name := range params {
+		if _, ok := commonParams[key]; ok {
+			return fmt.Errorf("parameter %s is common to all flows", key)
+		}
+
+		t, ok := paramTypeByName(name)
+
+		if !ok {
+			return fmt.Errorf("unknown type %s of parameter %s", name, key)
+		}
+
+		types[key] = t
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	r, ok := registeredFlows[flowType]
+
+	if !ok {
+		return fmt.Errorf("flow type %d not registered", flowType)
+	}
+
+	r.params = types
+	registeredFlows[flowType] = r
+
+	return nil
+}
+
+// RegisteredFlows returns the flow types added with RegisterFlow, by name.
+func RegisteredFlows() map[string]FlowType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	flows := make(map[string]FlowType, len(registeredFlows))
+
+	for flowType, r := range registeredFlows {
+		flows[r.name] = flowType
+	}
+
+	return flows
+}
+
+func registeredHandler(flowType FlowType) FlowHandler {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return registeredFlows[flowType].handler
+}
+
+func registeredParams(flowType FlowType) map[string]paramType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return registeredFlows[flowType].params
+}
+
+// FlowContext gives registered flows access to the card and to the helpers of
+// the built-in flows. It is only valid during the call to the handler and must
+// not be used from other goroutines.
+type FlowContext struct {
+	f  *KeycardFlow
+	kc *keycardContext
+}
+
+// Param returns a parameter of the flow, including those given on Resume.
+// Parameters not declared with RegisterFlowParams are not checked, they hold
+// whatever the caller passed, typically as decoded from JSON, and the handler
+// must check their type.
+func (c *FlowContext) Param(key string) (interface{}, bool) {
+	v, ok := c.f.params[key]
+	return v, ok
+}
+
+// SetParam sets a parameter of the flow, for instance to keep a value across
+// a restart.
+func (c *FlowContext) SetParam(key string, value interface{}) {
+	c.f.params[key] = value
+}
+
+// DeleteParam removes a parameter, so that the next pause prompts for it.
+func (c *FlowContext) DeleteParam(key string) {
+	delete(c.f.params, key)
+}
+
+func (c *FlowContext) InstanceUID() string {
+	return c.f.cardInfo.instanceUID
+}
+
+func (c *FlowContext) KeyUID() string {
+	return c.f.cardInfo.keyUID
+}
+
+func (c *FlowContext) ApplicationInfo() ApplicationInfo {
+	return toAppInfo(c.kc.cmdSet.ApplicationInfo)
+}
+
+// RequireKeys asks for another card unless the card has keys.
+func (c *FlowContext) RequireKeys() error {
+	return c.f.requireKeys()
+}
+
+// RequireNoKeys asks for another card if the card has keys, unless Overwrite
+// is set.
+func (c *FlowContext) RequireNoKeys() error {
+	return c.f.requireNoKeys()
+}
+
+// OpenSCAndAuthenticate pairs if needed, opens the secure channel and verifies
+// the PIN, prompting for it when missing. With giveup, unpaired cards are not
+// paired and giveup errors are returned instead.
+func (c *FlowContext) OpenSCAndAuthenticate(giveup bool) error {
+	return c.f.openSCAndAuthenticate(c.kc, giveup)
+}
+
+// PauseAndWait signals action and waits until the flow is resumed.
+func (c *FlowContext) PauseAndWait(action string, errMsg string) error {
+	return c.f.pauseAndWait(action, errMsg)
+}
+
+// PauseAndWaitWithStatus is like PauseAndWait, with status added to the
+// signal.
+func (c *FlowContext) PauseAndWaitWithStatus(action string, errMsg string, status FlowParams) error {
+	return c.f.pauseAndWaitWithStatus(action, errMsg, status)
+}
+
+// ExportKey exports the key at path, which requires an authenticated secure
+// channel.
+func (c *FlowContext) ExportKey(path string, private bool) (*KeyPair, error) {
+	return c.f.exportKey(c.kc, path, !private)
+}
+
+// Sign signs hash with the key at path.
+func (c *FlowContext) Sign(hash []byte, path string) (*Signature, error) {
+	signature, err := c.kc.signWithPath(hash, path)
+
+	if err != nil {
+		return nil, cardErr(err)
+	}
+
+	return toSignature(hash, signature), nil
+}
+
+// GetData reads the data object typ of the card.
+func (c *FlowContext) GetData(typ uint8) ([]byte, error) {
+	data, err := c.kc.cmdSet.GetData(typ)
+	return data, cardErr(err)
+}
+
+// StoreData writes the data object typ of the card.
+func (c *FlowContext) StoreData(typ uint8, data []byte) error {
+	return cardErr(c.kc.cmdSet.StoreData(typ, data))
+}
+
+// cardErr restarts the flow on connection errors, like the built-in commands.
+func cardErr(err error) error {
+	if isSCardError(err) {
+		return restartErr()
+	}
+
+	return err
+}
@@ -0,0 +1,94 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"testing"
+)
+
+const (
+	testCustomFlow FlowType = CustomFlowBase + iota
+	testUnregisteredFlow
+)
+
+const testLabel = "label"
+
+// the registry is global, the test flow is registered once for all test runs
+func init() {
+	err := RegisterFlow(testCustomFlow, "test-label", func(c *FlowContext) (FlowStatus, error) {
+		if err := c.OpenSCAndAuthenticate(false); err != nil {
+			return nil, err
+		}
+
+		label, _ := c.Param(testLabel)
+
+		return FlowStatus{ErrorKey: ErrorOK, testLabel: label, InstanceUID: c.InstanceUID()}, nil
+	})
+
+	if err == nil {
+		err = RegisterFlowParams(testCustomFlow, map[string]string{testLabel: "string"})
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterFlow(t *testing.T) {
+	handler := func(c *FlowContext) (FlowStatus, error) { return nil, nil }
+
+	if err := RegisterFlow(Sign, "sign", handler); err == nil {
+		t.Error("registered a reserved flow type")
+	}
+
+	if err := RegisterFlow(testUnregisteredFlow, "nil", nil); err == nil {
+		t.Error("registered a flow without handler")
+	}
+
+	if err := RegisterFlow(testCustomFlow, "again", handler); err == nil {
+		t.Error("registered a flow type twice")
+	}
+
+	if err := RegisterFlowParams(testUnregisteredFlow, map[string]string{testLabel: "string"}); err == nil {
+		t.Error("declared the parameters of an unregistered flow")
+	}
+
+	if err := RegisterFlowParams(testCustomFlow, map[string]string{testLabel: "text"}); err == nil {
+		t.Error("declared a parameter of unknown type")
+	}
+
+	if err := RegisterFlowParams(testCustomFlow, map[string]string{PIN: "integer"}); err == nil {
+		t.Error("redeclared a common parameter")
+	}
+
+	if flows := RegisteredFlows(); flows["test-label"] != testCustomFlow {
+		t.Errorf("unexpected flows %+v", flows)
+	}
+}
+
+func TestRegisteredFlowDispatch(t *testing.T) {
+	emu, card := newTestEmulator(t)
+	f := newTestFlowWithTransport(t, emu.NewTransport)
+	ctx := context.Background()
+
+	result, err := f.RunFlow(ctx, testCustomFlow, FlowParams{PIN: testPIN, testLabel: "main"}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result[ErrorKey] != ErrorOK || result[testLabel] != "main" || result[InstanceUID] != card.InstanceUID() {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if _, ok := f.Start(testCustomFlow, FlowParams{testLabel: 1}).(*ParamError); !ok {
+		t.Fatal("started with an invalid parameter")
+	}
+
+	result, err = f.RunFlow(ctx, testUnregisteredFlow, FlowParams{}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result[ErrorKey] != ErrorUnknownFlow {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
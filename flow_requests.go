@@ -0,0 +1,375 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// FlowRequest holds the parameters shared by all flows. Empty fields are left
+// out, so that the flow asks for them when needed. Timeouts are in seconds.
+type FlowRequest struct {
+	InstanceUID       string         `json:"instance-uid,omitempty"`
+	KeyUID            string         `json:"key-uid,omitempty"`
+	ReaderName        string         `json:"reader-name,omitempty"`
+	FactoryReset      bool           `json:"factory reset,omitempty"`
+	PairingPass       string         `json:"pairing-pass,omitempty"`
+	PIN               string         `json:"pin,omitempty"`
+	PUK               string         `json:"puk,omitempty"`
+	NewPIN            string         `json:"new-pin,omitempty"`
+	NewPUK            string         `json:"new-puk,omitempty"`
+	NewPairing        string         `json:"new-pairing-pass,omitempty"`
+	FlowTimeout       int            `json:"flow-timeout,omitempty"`
+	PauseTimeout      int            `json:"pause-timeout,omitempty"`
+	ActionTimeouts    map[string]int `json:"action-timeouts,omitempty"`
+	HeartbeatInterval int            `json:"heartbeat-interval,omitempty"`
+}
+
+// FlowResponse holds the fields shared by all flow results. Error is ErrorOK,
+// or empty, on success, ErrorInfo details the failures.
+type FlowResponse struct {
+	Error       string     `json:"error,omitempty"`
+	ErrorInfo   *FlowError `json:"error-info,omitempty"`
+	InstanceUID string     `json:"instance-uid,omitempty"`
+	KeyUID      string     `json:"key-uid,omitempty"`
+	ReaderName  string     `json:"reader-name,omitempty"`
+}
+
+// GetAppInfoResult holds the retry counters only if the card could be paired,
+// a blocked PIN or PUK has 0 retries left.
+type GetAppInfoResult struct {
+	FlowResponse
+	AppInfo    *ApplicationInfo `json:"application-info,omitempty"`
+	Paired     bool             `json:"paired"`
+	PINRetries *int             `json:"pin-retries,omitempty"`
+	PUKRetries *int             `json:"puk-retries,omitempty"`
+}
+
+type LoginResult struct {
+	FlowResponse
+	EncryptionKey *KeyPair `json:"encryption-key,omitempty"`
+	WhisperKey    *KeyPair `json:"whisper-key,omitempty"`
+}
+
+type RecoverAccountResult struct {
+	LoginResult
+	EIP1581Key    *KeyPair `json:"eip1581-key,omitempty"`
+	WalletRootKey *KeyPair `json:"wallet-root-key,omitempty"`
+	WalletKey     *KeyPair `json:"wallet-key,omitempty"`
+	MasterKey     *KeyPair `json:"master-key,omitempty"`
+}
+
+type LoadAccountRequest struct {
+	FlowRequest
+	Mnemonic       string `json:"mnemonic,omitempty"`
+	MnemonicLength int    `json:"mnemonic-length,omitempty"`
+	Overwrite      bool   `json:"overwrite,omitempty"`
+}
+
+// DerivationPaths is the bip44-path parameter of ExportPublic: a single path,
+// or a list of paths if List is set.
+type DerivationPaths struct {
+	Paths []string
+	List  bool
+}
+
+type ExportPublicRequest struct {
+	FlowRequest
+	BIP44Path     *DerivationPaths `json:"bip44-path,omitempty"`
+	ExportMaster  bool             `json:"export-master-address,omitempty"`
+	ExportPrivate bool             `json:"export-private,omitempty"`
+}
+
+// ExportedKeys is the exported-key result of ExportPublic, holding a list of
+// keys if a list of paths was requested.
+type ExportedKeys struct {
+	Keys []*KeyPair
+	List bool
+}
+
+type ExportPublicResult struct {
+	FlowResponse
+	MasterKeyAddress string        `json:"master-key-address,omitempty"`
+	ExportedKey      *ExportedKeys `json:"exported-key,omitempty"`
+}
+
+type SignRequest struct {
+	FlowRequest
+	BIP44Path string `json:"bip44-path,omitempty"`
+	TXHash    string `json:"tx-hash,omitempty"`
+	ChainID   int    `json:"chain-id,omitempty"`
+}
+
+type SignResult struct {
+	FlowResponse
+	Signature *Signature `json:"tx-signature,omitempty"`
+}
+
+// SignTransactionRequest signs Transaction, either a *TransactionArgs or the
+// RLP encoding of the unsigned transaction as hex. ChainID is only needed if
+// the transaction does not carry it.
+type SignTransactionRequest struct {
+	FlowRequest
+	BIP44Path   string      `json:"bip44-path,omitempty"`
+	Transaction interface{} `json:"transaction,omitempty"`
+	ChainID     int         `json:"chain-id,omitempty"`
+}
+
+type SignTransactionResult struct {
+	FlowResponse
+	Signature         *Signature `json:"tx-signature,omitempty"`
+	SignedTransaction string     `json:"signed-transaction,omitempty"`
+	TransactionHash   string     `json:"transaction-hash,omitempty"`
+	Sender            string     `json:"sender,omitempty"`
+}
+
+// SignTypedDataRequest signs TypedData, the eth_signTypedData_v4 payload as
+// JSON string or as value marshalled to it.
+type SignTypedDataRequest struct {
+	FlowRequest
+	BIP44Path string      `json:"bip44-path,omitempty"`
+	TypedData interface{} `json:"typed-data,omitempty"`
+}
+
+// SignTypedDataResult holds the signature in both forms, RawSignature as
+// returned by eth_signTypedData, along with the hashes it was computed from.
+type SignTypedDataResult struct {
+	FlowResponse
+	Signature       *Signature `json:"tx-signature,omitempty"`
+	RawSignature    string     `json:"signature,omitempty"`
+	DomainSeparator string     `json:"domain-separator,omitempty"`
+	MessageHash     string     `json:"message-hash,omitempty"`
+	SignedHash      string     `json:"signed-hash,omitempty"`
+}
+
+// SignMessageRequest signs a message with personal_sign, given either as
+// text, or as bytes in hex with MessageHex.
+type SignMessageRequest struct {
+	FlowRequest
+	BIP44Path  string `json:"bip44-path,omitempty"`
+	Message    string `json:"message,omitempty"`
+	MessageHex string `json:"message-hex,omitempty"`
+}
+
+// SignMessageResult holds the signature as returned by personal_sign and the
+// address recovered from it.
+type SignMessageResult struct {
+	FlowResponse
+	Signature    *Signature `json:"tx-signature,omitempty"`
+	RawSignature string     `json:"signature,omitempty"`
+	SignedHash   string     `json:"signed-hash,omitempty"`
+	Address      string     `json:"address,omitempty"`
+}
+
+// SignBatchItem is a hash to sign with SignBatch. BIP44Path defaults to the
+// one of the request.
+type SignBatchItem struct {
+	TXHash    string `json:"tx-hash"`
+	BIP44Path string `json:"bip44-path,omitempty"`
+}
+
+type SignBatchRequest struct {
+	FlowRequest
+	BIP44Path string          `json:"bip44-path,omitempty"`
+	Items     []SignBatchItem `json:"sign-items,omitempty"`
+	ChainID   int             `json:"chain-id,omitempty"`
+}
+
+// SignBatchResult holds a signature or an error for each item, in the order
+// of the request.
+type SignBatchResult struct {
+	FlowResponse
+	Signatures []BatchSignature `json:"signatures,omitempty"`
+}
+
+type UnpairResult struct {
+	FlowResponse
+	FreeSlots int `json:"free-pairing-slots"`
+}
+
+type StoreMetadataRequest struct {
+	FlowRequest
+	CardName    string   `json:"card-name,omitempty"`
+	WalletPaths []string `json:"wallet-paths,omitempty"`
+}
+
+type GetMetadataRequest struct {
+	FlowRequest
+	ResolveAddresses bool `json:"resolve-addresses,omitempty"`
+	ExportMaster     bool `json:"export-master-address,omitempty"`
+}
+
+type GetMetadataResult struct {
+	FlowResponse
+	MasterKeyAddress string    `json:"master-key-address,omitempty"`
+	CardMetadata     *Metadata `json:"card-metadata,omitempty"`
+}
+
+func (p DerivationPaths) MarshalJSON() ([]byte, error) {
+	if !p.List && len(p.Paths) == 1 {
+		return json.Marshal(p.Paths[0])
+	}
+
+	return json.Marshal(p.Paths)
+}
+
+func (p *DerivationPaths) UnmarshalJSON(data []byte) error {
+	p.List = bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+
+	if p.List {
+		return json.Unmarshal(data, &p.Paths)
+	}
+
+	var path string
+	err := json.Unmarshal(data, &path)
+	p.Paths = []string{path}
+
+	return err
+}
+
+func (k ExportedKeys) MarshalJSON() ([]byte, error) {
+	if !k.List && len(k.Keys) == 1 {
+		return json.Marshal(k.Keys[0])
+	}
+
+	return json.Marshal(k.Keys)
+}
+
+func (k *ExportedKeys) UnmarshalJSON(data []byte) error {
+	k.List = bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+
+	if k.List {
+		return json.Unmarshal(data, &k.Keys)
+	}
+
+	key := &KeyPair{}
+	err := json.Unmarshal(data, key)
+	k.Keys = []*KeyPair{key}
+
+	return err
+}
+
+// ToFlowParams converts a typed request to the parameters accepted by Start,
+// exactly as they would be decoded from JSON by the C API.
+func ToFlowParams(request interface{}) (FlowParams, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeFlowParams(data)
+}
+
+// FromFlowStatus fills a typed result from a FlowResult status.
+func FromFlowStatus(status FlowStatus, result interface{}) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+func (f *KeycardFlow) runTyped(ctx context.Context, flowType FlowType, request interface{}, prompter Prompter, result interface{}) error {
+	params, err := ToFlowParams(request)
+	if err != nil {
+		return err
+	}
+
+	status, err := f.RunFlow(ctx, flowType, params, prompter)
+	if err != nil {
+		return err
+	}
+
+	return FromFlowStatus(status, result)
+}
+
+func (f *KeycardFlow) GetAppInfo(ctx context.Context, request *FlowRequest, prompter Prompter) (*GetAppInfoResult, error) {
+	result := &GetAppInfoResult{}
+	return result, f.runTyped(ctx, GetAppInfo, request, prompter, result)
+}
+
+func (f *KeycardFlow) RecoverAccount(ctx context.Context, request *FlowRequest, prompter Prompter) (*RecoverAccountResult, error) {
+	result := &RecoverAccountResult{}
+	return result, f.runTyped(ctx, RecoverAccount, request, prompter, result)
+}
+
+func (f *KeycardFlow) LoadAccount(ctx context.Context, request *LoadAccountRequest, prompter Prompter) (*FlowResponse, error) {
+	result := &FlowResponse{}
+	return result, f.runTyped(ctx, LoadAccount, request, prompter, result)
+}
+
+func (f *KeycardFlow) Login(ctx context.Context, request *FlowRequest, prompter Prompter) (*LoginResult, error) {
+	result := &LoginResult{}
+	return result, f.runTyped(ctx, Login, request, prompter, result)
+}
+
+func (f *KeycardFlow) ExportPublic(ctx context.Context, request *ExportPublicRequest, prompter Prompter) (*ExportPublicResult, error) {
+	result := &ExportPublicResult{}
+	return result, f.runTyped(ctx, ExportPublic, request, prompter, result)
+}
+
+func (f *KeycardFlow) Sign(ctx context.Context, request *SignRequest, prompter Prompter) (*SignResult, error) {
+	result := &SignResult{}
+	return result, f.runTyped(ctx, Sign, request, prompter, result)
+}
+
+func (f *KeycardFlow) SignTransaction(ctx context.Context, request *SignTransactionRequest, prompter Prompter) (*SignTransactionResult, error) {
+	result := &SignTransactionResult{}
+	return result, f.runTyped(ctx, SignTransaction, request, prompter, result)
+}
+
+func (f *KeycardFlow) SignTypedData(ctx context.Context, request *SignTypedDataRequest, prompter Prompter) (*SignTypedDataResult, error) {
+	result := &SignTypedDataResult{}
+	return result, f.runTyped(ctx, SignTypedData, request, prompter, result)
+}
+
+func (f *KeycardFlow) SignMessage(ctx context.Context, request *SignMessageRequest, prompter Prompter) (*SignMessageResult, error) {
+	result := &SignMessageResult{}
+	return result, f.runTyped(ctx, SignMessage, request, prompter, result)
+}
+
+func (f *KeycardFlow) SignBatch(ctx context.Context, request *SignBatchRequest, prompter Prompter) (*SignBatchResult, error) {
+	result := &SignBatchResult{}
+	return result, f.runTyped(ctx, SignBatch, request, prompter, result)
+}
+
+func (f *KeycardFlow) ChangePIN(ctx context.Context, request *FlowRequest, prompter Prompter) (*FlowResponse, error) {
+	result := &FlowResponse{}
+	return result, f.runTyped(ctx, ChangePIN, request, prompter, result)
+}
+
+func (f *KeycardFlow) ChangePUK(ctx context.Context, request *FlowRequest, prompter Prompter) (*FlowResponse, error) {
+	result := &FlowResponse{}
+	return result, f.runTyped(ctx, ChangePUK, request, prompter, result)
+}
+
+func (f *KeycardFlow) ChangePairing(ctx context.Context, request *FlowRequest, prompter Prompter) (*FlowResponse, error) {
+	result := &FlowResponse{}
+	return result, f.runTyped(ctx, ChangePairing, request, prompter, result)
+}
+
+func (f *KeycardFlow) UnpairThis(ctx context.Context, request *FlowRequest, prompter Prompter) (*UnpairResult, error) {
+	result := &UnpairResult{}
+	return result, f.runTyped(ctx, UnpairThis, request, prompter, result)
+}
+
+func (f *KeycardFlow) UnpairOthers(ctx context.Context, request *FlowRequest, prompter Prompter) (*UnpairResult, error) {
+	result := &UnpairResult{}
+	return result, f.runTyped(ctx, UnpairOthers, request, prompter, result)
+}
+
+func (f *KeycardFlow) DeleteAccountAndUnpair(ctx context.Context, request *FlowRequest, prompter Prompter) (*UnpairResult, error) {
+	result := &UnpairResult{}
+	return result, f.runTyped(ctx, DeleteAccountAndUnpair, request, prompter, result)
+}
+
+func (f *KeycardFlow) StoreMetadata(ctx context.Context, request *StoreMetadataRequest, prompter Prompter) (*FlowResponse, error) {
+	result := &FlowResponse{}
+	return result, f.runTyped(ctx, StoreMetadata, request, prompter, result)
+}
+
+func (f *KeycardFlow) GetMetadata(ctx context.Context, request *GetMetadataRequest, prompter Prompter) (*GetMetadataResult, error) {
+	result := &GetMetadataResult{}
+	return result, f.runTyped(ctx, GetMetadata, request, prompter, result)
+}
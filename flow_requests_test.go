@@ -0,0 +1,142 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestToFlowParams(t *testing.T) {
+	request := &ExportPublicRequest{
+		FlowRequest:  FlowRequest{PIN: testPIN, FlowTimeout: 30, ActionTimeouts: map[string]int{EnterPIN: 10}},
+		BIP44Path:    &DerivationPaths{Paths: []string{"m/44'/60'/0'/0/0"}, List: true},
+		ExportMaster: true,
+	}
+
+	params, err := ToFlowParams(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := FlowParams{
+		PIN:          testPIN,
+		FlowTimeout:  json.Number("30"),
+		ActTimeouts:  map[string]interface{}{EnterPIN: json.Number("10")},
+		BIP44Path:    []interface{}{"m/44'/60'/0'/0/0"},
+		ExportMaster: true,
+	}
+
+	if !reflect.DeepEqual(params, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, params)
+	}
+
+	if err := validateParams(ExportPublic, params); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromFlowStatusRetries(t *testing.T) {
+	tests := []struct {
+		status FlowStatus
+		pin    *int
+		puk    *int
+	}{
+		{FlowStatus{Paired: false}, nil, nil},
+		{FlowStatus{Paired: true, PINRetries: 3, PUKRetries: 5}, intPtr(3), intPtr(5)},
+		{FlowStatus{Paired: true, PINRetries: 0, PUKRetries: 0}, intPtr(0), intPtr(0)},
+	}
+
+	for _, test := range tests {
+		result := &GetAppInfoResult{}
+
+		if err := FromFlowStatus(test.status, result); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(result.PINRetries, test.pin) || !reflect.DeepEqual(result.PUKRetries, test.puk) {
+			t.Errorf("%+v: got %v %v", test.status, result.PINRetries, result.PUKRetries)
+		}
+
+		// the counters survive the round trip to the C API format
+		data, _ := json.Marshal(result)
+		status := FlowStatus{}
+		_ = json.Unmarshal(data, &status)
+
+		if _, ok := status[PINRetries]; ok != (test.pin != nil) {
+			t.Errorf("%+v: marshalled as %s", test.status, data)
+		}
+	}
+}
+
+func TestTypedFlow(t *testing.T) {
+	emu, card := newTestEmulator(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestFlowWithTransport(t, emu.NewTransport)
+	ctx := context.Background()
+
+	exported, err := f.ExportPublic(ctx, &ExportPublicRequest{
+		FlowRequest: FlowRequest{PIN: testPIN},
+		BIP44Path:   &DerivationPaths{Paths: []string{"m/44'/60'/0'/0/0", "m/44'/60'/0'/0/1"}, List: true},
+	}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exported.ExportedKey == nil || len(exported.ExportedKey.Keys) != 2 || exported.ExportedKey.Keys[0].Address != testAddress {
+		t.Fatalf("unexpected result %+v", exported)
+	}
+
+	// GetAppInfo only reports the retry counters of paired cards
+	info, err := f.GetAppInfo(ctx, &FlowRequest{PIN: testPIN}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.Paired || info.PINRetries == nil || *info.PINRetries != maxPINRetries || info.AppInfo == nil || btox(info.AppInfo.KeyUID) != card.KeyUID() {
+		t.Fatalf("unexpected result %+v", info)
+	}
+}
+
+func TestParamAccessors(t *testing.T) {
+	params := FlowParams{
+		PIN:         float64(123456),
+		Overwrite:   "true",
+		ChainID:     float64(1.5),
+		MnemonicLen: float64(12),
+		WalletPaths: []interface{}{"m/1", 2},
+		SignItems:   []interface{}{map[string]interface{}{TXHash: "00"}},
+	}
+
+	if _, ok := params.stringValue(PIN); ok {
+		t.Error("number read as string")
+	}
+
+	if params.boolValue(Overwrite) {
+		t.Error("string read as bool")
+	}
+
+	if _, ok := params.intValue(ChainID); ok {
+		t.Error("fraction read as integer")
+	}
+
+	if n, ok := params.intValue(MnemonicLen); !ok || n != 12 {
+		t.Errorf("integral float read as %d %v", n, ok)
+	}
+
+	if _, ok := params.stringList(WalletPaths); ok {
+		t.Error("mixed list read as string list")
+	}
+
+	if items, ok := params.objectList(SignItems); !ok || items[0][TXHash] != "00" {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
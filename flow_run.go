@@ -0,0 +1,146 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"errors"
+)
+
+// Prompter answers the actions of a flow run with RunFlow.
+type Prompter interface {
+	// Prompt is called when the flow pauses on an action requiring input, like
+	// EnterPIN, EnterPUK or SwapCard. The returned parameters are used to
+	// resume the flow, an error cancels it. The context is done once the
+	// answer is no longer needed: the flow ended, for instance on a timeout,
+	// or the card was removed, in which case the action is prompted again
+	// once the card is back.
+	Prompt(ctx context.Context, action string, status FlowStatus) (FlowParams, error)
+	// Notify reports the actions the flow handles on its own: InsertCard,
+	// CardInserted and CardRemoved, as well as FlowHeartbeat and SignProgress.
+	// It may be called while Prompt is pending.
+	Notify(action string, status FlowStatus)
+}
+
+type flowEvent struct {
+	typ    string
+	status FlowStatus
+}
+
+type promptAnswer struct {
+	seq    int
+	params FlowParams
+	err    error
+}
+
+// RunFlow runs a flow to completion and returns its result. Signals of the
+// flow are delivered to prompter instead of the signal handler. The flow is
+// cancelled, and ctx.Err() returned, as soon as ctx is done. Flow errors are
+// reported in the result, like in FlowResult signals.
+func (f *KeycardFlow) RunFlow(ctx context.Context, flowType FlowType, params FlowParams, prompter Prompter) (FlowStatus, error) {
+	events := make(chan flowEvent, 16)
+
+	notify := func(typ string, event interface{}) {
+		var status FlowStatus
+
+		switch e := event.(type) {
+		case FlowStatus:
+			status = e
+		case FlowParams:
+			status = FlowStatus(e)
+		}
+
+		events <- flowEvent{typ, status}
+	}
+
+	// the flow is identified by its ID from now on: once it ends, a queued
+	// flow might start and must not be resumed or cancelled from here
+	flowID, done, err := f.start(flowType, params, notify)
+	if err != nil {
+		return nil, err
+	}
+
+	answers := make(chan promptAnswer)
+	finished := make(chan struct{})
+	defer close(finished)
+
+	// seq identifies the pending prompt, answers to previous ones are dropped
+	seq := 0
+	cancelPrompt := func() {}
+	defer func() { cancelPrompt() }()
+
+	prompt := func(e flowEvent) {
+		var promptCtx context.Context
+
+		cancelPrompt()
+		seq++
+		promptCtx, cancelPrompt = context.WithCancel(ctx)
+
+		go func(seq int) {
+			params, err := prompter.Prompt(promptCtx, e.typ, e.status)
+
+			select {
+			case answers <- promptAnswer{seq, params, err}:
+			case <-finished:
+			}
+		}(seq)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			f.cancelAndWait(flowID, done, events)
+			return nil, ctx.Err()
+		case e := <-events:
+			switch e.typ {
+			case FlowResult:
+				return e.status, nil
+			case CardRemoved:
+				cancelPrompt()
+				seq++
+				prompter.Notify(e.typ, e.status)
+			case InsertCard, CardInserted, FlowHeartbeat, SignProgress:
+				prompter.Notify(e.typ, e.status)
+			default:
+				prompt(e)
+			}
+		case a := <-answers:
+			if a.seq != seq {
+				continue
+			}
+
+			if a.err != nil {
+				f.cancelAndWait(flowID, done, events)
+				return nil, a.err
+			}
+
+			if err := f.ResumeFlow(flowID, a.params); err != nil {
+				l("resuming flow failed %+v", err)
+			}
+		case <-done:
+			// the result, if any, is queued before done is closed
+			for {
+				select {
+				case e := <-events:
+					if e.typ == FlowResult {
+						return e.status, nil
+					}
+				default:
+					return nil, errors.New(ErrorCancel)
+				}
+			}
+		}
+	}
+}
+
+// cancelAndWait cancels the flow and waits for it to end, dropping its events
+// meanwhile so that it does not block on notify.
+func (f *KeycardFlow) cancelAndWait(flowID uint64, done chan (struct{}), events chan flowEvent) {
+	_ = f.CancelFlow(flowID)
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-events:
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// slowPrompter cancels every prompt and blocks on SignProgress until ctx is
+// done.
+type slowPrompter struct {
+	ctx context.Context
+}
+
+func (p *slowPrompter) Prompt(ctx context.Context, action string, status FlowStatus) (FlowParams, error) {
+	return nil, context.Canceled
+}
+
+func (p *slowPrompter) Notify(action string, status FlowStatus) {
+	if action == SignProgress {
+		<-p.ctx.Done()
+	}
+}
+
+func TestRunFlowCancelWhileNotifying(t *testing.T) {
+	recordSignals(t)
+	f := newTestFlow(t)
+
+	_, err := f.RunFlow(context.Background(), LoadAccount, FlowParams{PIN: testPIN, Mnemonic: testMnemonic}, &slowPrompter{context.Background()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// more items than the events RunFlow buffers, with Notify blocking until
+	// the flow is cancelled
+	items := []interface{}{}
+
+	for i := 0; i < 40; i++ {
+		items = append(items, map[string]interface{}{TXHash: "50b2c43fd39106bafbba0da34fc430e1f91e3c96ea2acee2bc34119f92b37750"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	result := make(chan error, 1)
+
+	go func() {
+		_, err := f.RunFlow(ctx, SignBatch, FlowParams{PIN: testPIN, BIP44Path: "m/44'/60'/0'/0/0", SignItems: items}, &slowPrompter{ctx})
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		if err != context.DeadlineExceeded {
+			t.Fatalf("unexpected error %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("RunFlow did not return")
+	}
+}
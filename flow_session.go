@@ -0,0 +1,110 @@
+package statuskeycardgo
+
+import (
+	"fmt"
+)
+
+// operationParams are the parameters of a single session operation. They are
+// removed once the operation is done, so that the next one prompts for its own.
+var operationParams = []string{Operation, BIP44Path, ExportMaster, ExportPriv, TXHash, ChainID, CardName, WalletPaths}
+
+// sessionFlow authenticates once, then pauses on EnterOperation until resumed
+// with an Operation, runs it and pauses again with its result, until OpClose.
+// Failed operations are reported on EnterOperation as well, only cancelling,
+// timeouts and card errors end the session. The idle time of the session can
+// be bounded with an EnterOperation entry in ActTimeouts. If the card is
+// removed, the session authenticates again once it is back, with the same PIN.
+func (f *KeycardFlow) sessionFlow(kc *keycardContext) (FlowStatus, error) {
+	err := f.openSCAndAuthenticate(kc, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	errMsg := ErrorOK
+	status := FlowParams{}
+
+	for {
+		op, ok := f.params.stringValue(Operation)
+
+		if !ok {
+			err := f.pauseAndWaitWithStatus(EnterOperation, errMsg, status)
+
+			if err != nil {
+				return nil, err
+			}
+
+			errMsg = ErrorOK
+			status = FlowParams{}
+			continue
+		}
+
+		if op == OpClose {
+			f.clearOperation()
+			return FlowStatus{InstanceUID: f.cardInfo.instanceUID, KeyUID: f.cardInfo.keyUID}, nil
+		}
+
+		result, err := f.runOperation(kc, op)
+
+		switch err.(type) {
+		case nil:
+			status = FlowParams(result)
+		case *restartError, *giveupError:
+			return nil, err
+		default:
+			if isSCardError(err) {
+				return nil, restartErr()
+			}
+
+			flowErr := toFlowError(err)
+			errMsg = flowErr.Code
+			status = FlowParams{ErrorInfo: flowErr}
+		}
+
+		status[Operation] = op
+		f.clearOperation()
+	}
+}
+
+func (f *KeycardFlow) runOperation(kc *keycardContext, op string) (FlowStatus, error) {
+	switch op {
+	case OpLogin:
+		if err := f.requireKeys(); err != nil {
+			return nil, err
+		}
+
+		return f.exportKeys(kc, false)
+	case OpExportPublic:
+		if err := f.requireKeys(); err != nil {
+			return nil, err
+		}
+
+		return f.exportPublic(kc)
+	case OpSign:
+		if err := f.requireKeys(); err != nil {
+			return nil, err
+		}
+
+		signature, err := f.sign(kc)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return FlowStatus{KeyUID: f.cardInfo.keyUID, InstanceUID: f.cardInfo.instanceUID, TXSignature: signature}, nil
+	case OpStoreMetadata:
+		if err := f.storeMetadata(kc); err != nil {
+			return nil, err
+		}
+
+		return FlowStatus{KeyUID: f.cardInfo.keyUID, InstanceUID: f.cardInfo.instanceUID}, nil
+	default:
+		return nil, newFlowError(ErrorInvalidParam, fmt.Errorf("unknown operation %s", op))
+	}
+}
+
+func (f *KeycardFlow) clearOperation() {
+	for _, k := range operationParams {
+		delete(f.params, k)
+	}
+}
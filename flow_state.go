@@ -0,0 +1,68 @@
+package statuskeycardgo
+
+var runStateNames = map[runState]string{
+	Idle:       "idle",
+	Running:    "running",
+	Paused:     "paused",
+	Resuming:   "resuming",
+	Cancelling: "cancelling",
+}
+
+func (s runState) String() string {
+	return runStateNames[s]
+}
+
+// FlowState is a snapshot of a flow, for callers which did not follow its
+// signals, like a UI window attached while the flow runs. FlowType is nil if
+// no flow has been started yet. Status is the last action or result signalled
+// by the flow, PendingAction the action it is paused on, if any. RequestID is
+// set for enqueued flows, FlowID is the ID carried by the signals of the flow.
+// Queue lists the requests waiting for their turn.
+type FlowState struct {
+	State         string     `json:"state"`
+	FlowType      *FlowType  `json:"flowType,omitempty"`
+	FlowID        uint64     `json:"flowID,omitempty"`
+	RequestID     string     `json:"requestID,omitempty"`
+	PendingAction string     `json:"pendingAction,omitempty"`
+	Status        FlowStatus `json:"status,omitempty"`
+	ReaderName    string     `json:"readerName,omitempty"`
+	Queue         []string   `json:"queue,omitempty"`
+}
+
+// GetState returns what the flow is currently doing. Once the flow has ended,
+// it still describes the last one, along with its result.
+func (f *KeycardFlow) GetState() *FlowState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	state := &FlowState{
+		State:         f.state.String(),
+		RequestID:     f.requestID,
+		PendingAction: f.pendingAct,
+		Status:        copyStatus(f.lastStatus),
+		ReaderName:    f.reader,
+		Queue:         f.queuedRequests(),
+	}
+
+	if f.started {
+		flowType := f.flowType
+		state.FlowType = &flowType
+		state.FlowID = f.flowID
+	}
+
+	return state
+}
+
+func copyStatus(status map[string]interface{}) FlowStatus {
+	if status == nil {
+		return nil
+	}
+
+	c := make(FlowStatus, len(status))
+
+	for k, v := range status {
+		c[k] = v
+	}
+
+	return c
+}
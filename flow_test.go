@@ -0,0 +1,278 @@
+package statuskeycardgo
+
+import (
+	"encoding/json"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-keycard-go/signal"
+)
+
+type testSignal struct {
+	Type   string     `json:"type"`
+	Event  FlowStatus `json:"event"`
+	FlowID uint64     `json:"flowID"`
+}
+
+// recordSignals delivers the signals of the flows to the returned channel
+// until the test ends.
+func recordSignals(t *testing.T) chan testSignal {
+	signals := make(chan testSignal, 256)
+
+	signal.SetKeycardSignalHandler(func(data []byte) {
+		var s testSignal
+
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Errorf("invalid signal %s: %v", data, err)
+			return
+		}
+
+		select {
+		case signals <- s:
+		default:
+		}
+	})
+
+	t.Cleanup(func() { signal.SetKeycardSignalHandler(func([]byte) {}) })
+
+	return signals
+}
+
+func waitForSignal(t *testing.T, signals chan testSignal, typ string) FlowStatus {
+	t.Helper()
+
+	timeout := time.After(testTimeout)
+
+	for {
+		select {
+		case s := <-signals:
+			if s.Type == typ {
+				return s.Event
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", typ)
+		}
+	}
+}
+
+// newTestFlow returns a flow using an emulator with an initialized card.
+func newTestFlow(t *testing.T, opts ...FlowOption) *KeycardFlow {
+	t.Helper()
+
+	emu, _ := newTestEmulator(t)
+
+	return newTestFlowWithTransport(t, emu.NewTransport, opts...)
+}
+
+// newTestFlowWithTransport returns a flow using the given transport, with an
+// empty pairing store.
+func newTestFlowWithTransport(t *testing.T, newTransport TransportFactory, opts ...FlowOption) *KeycardFlow {
+	t.Helper()
+
+	f, err := NewFlowWithTransport(filepath.Join(t.TempDir(), "pairings.json"), newTransport, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+// waitForIdle cancels the running flow, if any, and waits until it is Idle.
+func waitForIdle(t *testing.T, getState func() *FlowState, cancel func() error) {
+	t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+
+	for getState().State != Idle.String() {
+		if time.Now().After(deadline) {
+			t.Fatalf("flow still %s", getState().State)
+		}
+
+		_ = cancel()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// hammer runs Start, Resume, Cancel and GetState concurrently from several
+// goroutines.
+func hammer(start func() error, resume func() error, cancel func() error, getState func() *FlowState) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(seed int64) {
+			defer wg.Done()
+
+			r := rand.New(rand.NewSource(seed))
+
+			for j := 0; j < 100; j++ {
+				switch r.Intn(4) {
+				case 0:
+					_ = start()
+				case 1:
+					_ = resume()
+				case 2:
+					_ = cancel()
+				case 3:
+					_ = getState()
+				}
+
+				time.Sleep(time.Duration(r.Intn(500)) * time.Microsecond)
+			}
+		}(int64(i))
+	}
+
+	wg.Wait()
+}
+
+func TestFlowPauseAndResume(t *testing.T) {
+	signals := recordSignals(t)
+	f := newTestFlow(t)
+
+	if err := f.Resume(FlowParams{PIN: testPIN}); err == nil {
+		t.Fatal("resumed an idle flow")
+	}
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterPIN)
+
+	if err := f.Start(ChangePIN, FlowParams{}); err == nil {
+		t.Fatal("started a flow while another is running")
+	}
+
+	if state := f.GetState(); state.State != Paused.String() || state.PendingAction != EnterPIN {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	if err := f.Resume(FlowParams{PIN: testPIN}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterNewPIN)
+
+	if err := f.Resume(FlowParams{NewPIN: "654321"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := waitForSignal(t, signals, FlowResult)
+
+	if _, failed := result[ErrorKey]; failed {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	waitForIdle(t, f.GetState, f.Cancel)
+}
+
+func TestFlowCancelWhilePaused(t *testing.T) {
+	signals := recordSignals(t)
+	f := newTestFlow(t)
+
+	if err := f.Cancel(); err == nil {
+		t.Fatal("cancelled an idle flow")
+	}
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterPIN)
+
+	if err := f.Cancel(); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForIdle(t, f.GetState, f.Cancel)
+
+	if err := f.Resume(FlowParams{PIN: testPIN}); err == nil {
+		t.Fatal("resumed a cancelled flow")
+	}
+}
+
+func TestSignalFlowID(t *testing.T) {
+	signals := recordSignals(t)
+	f := newTestFlow(t)
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var s testSignal
+	timeout := time.After(testTimeout)
+
+	for s.Type != EnterPIN {
+		select {
+		case s = <-signals:
+		case <-timeout:
+			t.Fatal("timed out waiting for the flow")
+		}
+	}
+
+	// the signals and the state of the flow spell the ID the same way
+	data, err := json.Marshal(f.GetState())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state struct {
+		FlowID uint64 `json:"flowID"`
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.FlowID == 0 || s.FlowID != state.FlowID {
+		t.Fatalf("signal of flow %d, state of flow %d", s.FlowID, state.FlowID)
+	}
+
+	waitForIdle(t, f.GetState, f.Cancel)
+}
+
+func TestFlowConcurrentStartResumeCancel(t *testing.T) {
+	recordSignals(t)
+	f := newTestFlow(t)
+
+	hammer(
+		func() error { return f.Start(ChangePIN, FlowParams{}) },
+		func() error { return f.Resume(FlowParams{PIN: testPIN}) },
+		f.Cancel,
+		f.GetState,
+	)
+
+	waitForIdle(t, f.GetState, f.Cancel)
+
+	// the flow is still usable
+	signals := recordSignals(t)
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterPIN)
+	waitForIdle(t, f.GetState, f.Cancel)
+}
+
+func TestMockedFlowConcurrentStartResumeCancel(t *testing.T) {
+	recordSignals(t)
+
+	f, err := NewMockedFlow(filepath.Join(t.TempDir(), "pairings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hammer(
+		func() error { return f.Start(GetAppInfo, FlowParams{}) },
+		func() error { return f.Resume(FlowParams{PIN: testPIN}) },
+		f.Cancel,
+		f.GetState,
+	)
+
+	waitForIdle(t, f.GetState, f.Cancel)
+}
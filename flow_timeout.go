@@ -0,0 +1,125 @@
+package statuskeycardgo
+
+import (
+	"math"
+	"time"
+)
+
+// pauseClock holds the deadline and the heartbeat of a pause. Its channels are
+// nil when not configured, so that they can always be selected on.
+type pauseClock struct {
+	action    string
+	deadline  time.Time
+	timer     *time.Timer
+	ticker    *time.Ticker
+	timeout   <-chan time.Time
+	heartbeat <-chan time.Time
+}
+
+// startPauseClock starts the clock of a pause on action, as configured by the
+// PauseTimeout, ActTimeouts and HeartbeatInt parameters. The flow deadline is
+// always part of the heartbeat. It must be called while the flow owns the
+// parameters.
+func (f *KeycardFlow) startPauseClock(action string) *pauseClock {
+	c := &pauseClock{action: action}
+
+	timeout := secondsParam(f.params[PauseTimeout])
+
+	if timeouts, ok := f.params[ActTimeouts].(map[string]interface{}); ok {
+		if t := secondsParam(timeouts[action]); t > 0 {
+			timeout = t
+		}
+	}
+
+	if timeout > 0 {
+		c.deadline = time.Now().Add(timeout)
+		c.timer = time.NewTimer(timeout)
+		c.timeout = c.timer.C
+	}
+
+	if interval := secondsParam(f.params[HeartbeatInt]); interval > 0 && (timeout > 0 || !f.deadline.IsZero()) {
+		c.ticker = time.NewTicker(interval)
+		c.heartbeat = c.ticker.C
+	}
+
+	return c
+}
+
+func (c *pauseClock) stop() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+
+	if c.ticker != nil {
+		c.ticker.Stop()
+	}
+}
+
+// beat signals the time left before the flow is cancelled, rounded up to the
+// second.
+func (f *KeycardFlow) beat(c *pauseClock) {
+	deadline := c.deadline
+
+	if deadline.IsZero() || (!f.deadline.IsZero() && f.deadline.Before(deadline)) {
+		deadline = f.deadline
+	}
+
+	remaining := math.Ceil(time.Until(deadline).Seconds())
+
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	f.notify(FlowHeartbeat, FlowStatus{PendingAct: c.action, Remaining: int(remaining)})
+}
+
+// expire cancels the flow on a timeout, the flow then ends with ErrorTimeout
+// instead of silently, unless the running command completes the flow anyway.
+// It has no effect if cancelled belongs to a previous run,
+// if the flow is already ending, or, with pausedOnly, if it has been resumed
+// meanwhile.
+func (f *KeycardFlow) expire(cancelled chan (struct{}), pausedOnly bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cancelled != cancelled || f.state == Idle || f.state == Cancelling {
+		return false
+	}
+
+	if pausedOnly && f.state != Paused {
+		return false
+	}
+
+	f.state = Cancelling
+	f.timedOut = true
+	close(cancelled)
+
+	return true
+}
+
+// pauseExpired handles the expiration of the pause clock. The flow gives up,
+// unless it has been resumed just before, in which case the wake up is
+// consumed.
+func (f *KeycardFlow) pauseExpired() error {
+	if f.expire(f.cancelled, true) {
+		return giveupErr()
+	}
+
+	select {
+	case <-f.wakeUp:
+		return nil
+	case <-f.cancelled:
+		return giveupErr()
+	}
+}
+
+func secondsParam(v interface{}) time.Duration {
+	switch n := v.(type) {
+	case int:
+		return time.Duration(n) * time.Second
+	case float64:
+		return time.Duration(n) * time.Second
+	}
+
+	return 0
+}
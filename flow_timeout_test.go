@@ -0,0 +1,93 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// slowTransport delays every APDU, so that deadlines expire while commands
+// run.
+type slowTransport struct {
+	Transport
+	delay time.Duration
+}
+
+type slowCard struct {
+	Card
+	delay time.Duration
+}
+
+func (t *slowTransport) Connect(reader string) (Card, error) {
+	card, err := t.Transport.Connect(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &slowCard{card, t.delay}, nil
+}
+
+func (c *slowCard) Transmit(apdu []byte) ([]byte, error) {
+	time.Sleep(c.delay)
+	return c.Card.Transmit(apdu)
+}
+
+// waitingPrompter never answers, prompts last until the flow ends.
+type waitingPrompter struct{}
+
+func (p *waitingPrompter) Prompt(ctx context.Context, action string, status FlowStatus) (FlowParams, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *waitingPrompter) Notify(action string, status FlowStatus) {}
+
+// slowTransports wraps newTransport so that every APDU is delayed.
+func slowTransports(newTransport TransportFactory, delay time.Duration) TransportFactory {
+	return func() (Transport, error) {
+		inner, err := newTransport()
+		if err != nil {
+			return nil, err
+		}
+
+		return &slowTransport{inner, delay}, nil
+	}
+}
+
+func newSlowTestFlow(t *testing.T, delay time.Duration) *KeycardFlow {
+	t.Helper()
+
+	emu, _ := newTestEmulator(t)
+
+	return newTestFlowWithTransport(t, slowTransports(emu.NewTransport, delay))
+}
+
+func TestFlowTimeoutWhilePaused(t *testing.T) {
+	f := newTestFlow(t)
+
+	result, err := f.RunFlow(context.Background(), ChangePIN, FlowParams{FlowTimeout: 1}, &waitingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result[ErrorKey] != ErrorTimeout {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestFlowTimeoutWhileRunningCommand(t *testing.T) {
+	f := newSlowTestFlow(t, 200*time.Millisecond)
+
+	params := FlowParams{FlowTimeout: 1, PIN: testPIN, NewPIN: "654321"}
+
+	result, err := f.RunFlow(context.Background(), ChangePIN, params, &waitingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the PIN changed, reporting a timeout would let the caller assume that
+	// the old one is still valid
+	if _, failed := result[ErrorKey]; failed {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
@@ -39,6 +39,11 @@ const (
 	DeleteAccountAndUnpair
 	StoreMetadata
 	GetMetadata
+	Session
+	SignTransaction
+	SignTypedData
+	SignMessage
+	SignBatch
 )
 
 const (
@@ -53,6 +58,9 @@ const (
 	FlowResult    = "keycard.flow-result"
 	InsertCard    = "keycard.action.insert-card"
 	CardInserted  = "keycard.action.card-inserted"
+	CardRemoved   = "keycard.action.card-removed"
+	FlowHeartbeat = "keycard.flow-heartbeat"
+	SignProgress  = "keycard.sign-progress"
 	SwapCard      = "keycard.action.swap-card"
 	EnterPairing  = "keycard.action.enter-pairing"
 	EnterPIN      = "keycard.action.enter-pin"
@@ -65,6 +73,19 @@ const (
 	EnterMnemonic = "keycard.action.enter-mnemonic"
 	EnterName     = "keycard.action.enter-cardname"
 	EnterWallets  = "keycard.action.enter-wallets"
+
+	EnterOperation   = "keycard.action.enter-operation"
+	EnterTransaction = "keycard.action.enter-transaction"
+	EnterTypedData   = "keycard.action.enter-typed-data"
+	EnterMessage     = "keycard.action.enter-message"
+	EnterSignItems   = "keycard.action.enter-sign-items"
+)
+
+const (
+	MonitorReaderAdded   = "keycard.reader-added"
+	MonitorReaderRemoved = "keycard.reader-removed"
+	MonitorCardPresent   = "keycard.card-present"
+	MonitorCardRemoved   = "keycard.card-removed"
 )
 
 const (
@@ -104,6 +125,41 @@ const (
 	CardMeta     = "card-metadata"
 	CardName     = "card-name"
 	WalletPaths  = "wallet-paths"
+	ReaderName   = "reader-name"
+	PendingAct   = "pending-action"
+	ErrorInfo    = "error-info"
+	FlowTimeout  = "flow-timeout"
+	PauseTimeout = "pause-timeout"
+	ActTimeouts  = "action-timeouts"
+	HeartbeatInt = "heartbeat-interval"
+	Remaining    = "remaining"
+	RequestID    = "request-id"
+	Operation    = "operation"
+	Transaction  = "transaction"
+	ChainID      = "chain-id"
+	SignedTX     = "signed-transaction"
+	TXHashOut    = "transaction-hash"
+	Sender       = "sender"
+	TypedData    = "typed-data"
+	RawSignature = "signature"
+	DomainHash   = "domain-separator"
+	MessageHash  = "message-hash"
+	SignedHash   = "signed-hash"
+	Message      = "message"
+	MessageHex   = "message-hex"
+	Address      = "address"
+	SignItems    = "sign-items"
+	Signatures   = "signatures"
+	ItemIndex    = "item-index"
+	ItemCount    = "item-count"
+)
+
+const (
+	OpLogin         = "login"
+	OpExportPublic  = "export-public"
+	OpSign          = "sign"
+	OpStoreMetadata = "store-metadata"
+	OpClose         = "close"
 )
 
 const (
@@ -1,12 +1,11 @@
 package statuskeycardgo
 
 import (
-	"crypto/sha512"
 	"errors"
 	"runtime"
+	"sync"
 	"time"
 
-	"github.com/ebfe/scard"
 	"github.com/ethereum/go-ethereum/crypto"
 	keycard "github.com/status-im/keycard-go"
 	"github.com/status-im/keycard-go/apdu"
@@ -14,12 +13,8 @@ import (
 	"github.com/status-im/keycard-go/identifiers"
 	"github.com/status-im/keycard-go/io"
 	"github.com/status-im/keycard-go/types"
-	"golang.org/x/crypto/pbkdf2"
-	"golang.org/x/text/unicode/norm"
 )
 
-const bip39Salt = "mnemonic"
-
 type commandType int
 
 const (
@@ -29,22 +24,44 @@ const (
 )
 
 type keycardContext struct {
-	cardCtx   *scard.Context
-	card      *scard.Card
-	readers   []string
-	c         types.Channel
-	cmdSet    *keycard.CommandSet
-	connected chan (bool)
-	command   chan (commandType)
-	apdu      []byte
-	rpdu      []byte
-	runErr    error
+	newTransport TransportFactory
+	filter       readerFilter
+	transportMu  sync.Mutex
+	transport    Transport
+	released     bool
+	card         Card
+	readers      []string
+	reader       string
+	c            types.Channel
+	cmdSet       *keycard.CommandSet
+	connected    chan (bool)
+	removed      chan (struct{})
+	watcher      *readerWatcher
+	command      chan (commandType)
+	apdu         []byte
+	rpdu         []byte
+	runErr       error
+	panicked     *flowPanic
+	// authenticated is set once the PIN is verified on the secure channel
+	authenticated bool
 }
 
 func (kc *keycardContext) Transmit(apdu []byte) ([]byte, error) {
 	kc.apdu = apdu
-	kc.command <- Transmit
-	<-kc.command
+
+	// connected is only closed, once connected, when the worker exits
+	select {
+	case kc.command <- Transmit:
+	case <-kc.connected:
+		return nil, kc.workerExited()
+	}
+
+	select {
+	case <-kc.command:
+	case <-kc.connected:
+		return nil, kc.workerExited()
+	}
+
 	kc.apdu = nil
 	rpdu, err := kc.rpdu, kc.runErr
 	kc.rpdu = nil
@@ -52,17 +69,23 @@ func (kc *keycardContext) Transmit(apdu []byte) ([]byte, error) {
 	return rpdu, err
 }
 
-func startKeycardContext() (*keycardContext, error) {
+func startKeycardContext(newTransport TransportFactory, filter readerFilter) (*keycardContext, error) {
 	kctx := &keycardContext{
-		connected: make(chan (bool)),
-		command:   make(chan (commandType)),
+		newTransport: newTransport,
+		filter:       filter,
+		connected:    make(chan (bool)),
+		removed:      make(chan (struct{})),
+		command:      make(chan (commandType)),
 	}
 
 	go kctx.run()
 
-	<-kctx.connected
+	// closed without a value if the transport could not be started
+	if started := <-kctx.connected; !started {
+		if kctx.panicked != nil {
+			panic(kctx.panicked)
+		}
 
-	if kctx.runErr != nil {
 		return nil, kctx.runErr
 	}
 
@@ -75,16 +98,27 @@ func (kc *keycardContext) run() {
 	var err error
 
 	defer func() {
+		if r := recover(); r != nil {
+			kc.panicked = recoveredPanic(r)
+			err = kc.panicked
+		}
+
 		if err != nil {
 			l(err.Error())
 		}
 
 		kc.runErr = err
 
-		if kc.cardCtx != nil {
-			_ = kc.cardCtx.Release()
+		if kc.watcher != nil {
+			kc.watcher.stop()
+		}
+
+		if kc.card != nil {
+			_ = kc.card.Disconnect()
 		}
 
+		kc.release()
+
 		close(kc.connected)
 		runtime.UnlockOSThread()
 	}()
@@ -117,34 +151,91 @@ func (kc *keycardContext) run() {
 }
 
 func (kc *keycardContext) start() error {
-	cardCtx, err := scard.EstablishContext()
+	transport, err := kc.newTransport()
 	if err != nil {
-		return errors.New(ErrorPCSC)
+		return err
 	}
 
+	kc.transportMu.Lock()
+	kc.transport = transport
+	kc.transportMu.Unlock()
+
 	l("listing readers")
-	readers, err := cardCtx.ListReaders()
+	readers, err := transport.ListReaders()
 	if err != nil {
-		return errors.New(ErrorReaderList)
+		return newFlowError(ErrorReaderList, err)
 	}
 
-	kc.readers = readers
+	kc.readers = kc.filter.apply(readers)
 
-	if len(readers) == 0 {
-		return errors.New(ErrorNoReader)
+	if len(kc.readers) == 0 {
+		return newFlowError(ErrorNoReader, nil)
 	}
 
-	kc.cardCtx = cardCtx
 	return nil
 }
 
+// workerExited panics in the flow goroutine if the worker thread did, so that
+// the flow ends with an internal error.
+func (kc *keycardContext) workerExited() error {
+	if kc.panicked != nil {
+		panic(kc.panicked)
+	}
+
+	return &TransportError{errors.New("card worker exited")}
+}
+
 func (kc *keycardContext) stop() {
 	close(kc.command)
 }
 
+// cancel aborts the wait for a card and returns once the worker thread has
+// released the transport.
+func (kc *keycardContext) cancel() {
+	for {
+		// the worker might not be waiting yet, keep cancelling until it exits
+		kc.cancelTransport()
+
+		select {
+		case _, ok := <-kc.connected:
+			if ok {
+				// the card was found in the meantime
+				kc.stop()
+
+				for range kc.connected {
+				}
+			}
+
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// cancelTransport cancels the pending wait of the worker, unless the transport
+// is being released.
+func (kc *keycardContext) cancelTransport() {
+	kc.transportMu.Lock()
+	defer kc.transportMu.Unlock()
+
+	if kc.transport != nil && !kc.released {
+		_ = kc.transport.Cancel()
+	}
+}
+
+func (kc *keycardContext) release() {
+	kc.transportMu.Lock()
+	kc.released = true
+	kc.transportMu.Unlock()
+
+	if kc.transport != nil {
+		_ = kc.transport.Release()
+	}
+}
+
 func (kc *keycardContext) connect() error {
 	l("waiting for card")
-	index, err := kc.waitForCard(kc.cardCtx, kc.readers)
+	index, err := kc.transport.WaitForCard(kc.readers)
 	if err != nil {
 		return err
 	}
@@ -154,57 +245,36 @@ func (kc *keycardContext) connect() error {
 
 	l("using reader %s", reader)
 
-	card, err := kc.cardCtx.Connect(reader, scard.ShareShared, scard.ProtocolAny)
+	card, err := kc.transport.Connect(reader)
 	if err != nil {
-		// error connecting to card
-		time.Sleep(500 * time.Millisecond)
 		return err
 	}
 
-	status, err := card.Status()
-	if err != nil {
-		time.Sleep(500 * time.Millisecond)
-		return err
-	}
-
-	switch status.ActiveProtocol {
-	case scard.ProtocolT0:
-		l("card protocol T0")
-	case scard.ProtocolT1:
-		l("card protocol T1")
-	default:
-		l("card protocol T unknown")
-	}
-
+	kc.reader = reader
 	kc.card = card
 	kc.c = io.NewNormalChannel(kc)
 	kc.cmdSet = keycard.NewCommandSet(kc.c)
 
+	kc.watcher, err = startReaderWatcher(kc.newTransport, kc.watchReader)
+	if err != nil {
+		l("cannot watch reader %+v", err)
+	}
+
 	return nil
 }
 
-func (kc *keycardContext) waitForCard(ctx *scard.Context, readers []string) (int, error) {
-	rs := make([]scard.ReaderState, len(readers))
-
-	for i := range rs {
-		rs[i].Reader = readers[i]
-		rs[i].CurrentState = scard.StateUnaware
+// watchReader closes kc.removed once the card is no longer in the reader.
+func (kc *keycardContext) watchReader(transport Transport, current []ReaderStatus) bool {
+	for _, rs := range current {
+		if rs.Reader == kc.reader && rs.CardPresent {
+			return true
+		}
 	}
 
-	for {
-		for i := range rs {
-			if rs[i].EventState&scard.StatePresent != 0 {
-				return i, nil
-			}
-
-			rs[i].CurrentState = rs[i].EventState
-		}
+	l("card removed from reader %s", kc.reader)
+	close(kc.removed)
 
-		err := ctx.GetStatusChange(rs, -1)
-		if err != nil {
-			return -1, err
-		}
-	}
+	return false
 }
 
 func (kc *keycardContext) selectApplet() (*types.ApplicationInfo, error) {
@@ -223,6 +293,10 @@ func (kc *keycardContext) selectApplet() (*types.ApplicationInfo, error) {
 }
 
 func (kc *keycardContext) pair(pairingPassword string) (*types.PairingInfo, error) {
+	if pl, ok := kc.card.(pairingPasswordListener); ok {
+		pl.setPairingPassword(pairingPassword)
+	}
+
 	err := kc.cmdSet.Pair(pairingPassword)
 	if err != nil {
 		l("pair failed %+v", err)
@@ -233,6 +307,10 @@ func (kc *keycardContext) pair(pairingPassword string) (*types.PairingInfo, erro
 }
 
 func (kc *keycardContext) openSecureChannel(index int, key []byte) error {
+	if pl, ok := kc.card.(pairingKeyListener); ok {
+		pl.setPairingKey(key)
+	}
+
 	kc.cmdSet.SetPairingInfo(key, index)
 	err := kc.cmdSet.OpenSecureChannel()
 	if err != nil {
@@ -357,8 +435,7 @@ func (kc *keycardContext) loadSeed(seed []byte) ([]byte, error) {
 }
 
 func (kc *keycardContext) loadMnemonic(mnemonic string, password string) ([]byte, error) {
-	seed := pbkdf2.Key(norm.NFKD.Bytes([]byte(mnemonic)), norm.NFKD.Bytes([]byte(bip39Salt+password)), 2048, 64, sha512.New)
-	return kc.loadSeed(seed)
+	return kc.loadSeed(mnemonicToSeed(mnemonic, password))
 }
 
 func (kc *keycardContext) init(pin, puk, pairingPassword string) error {
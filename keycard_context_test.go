@@ -0,0 +1,117 @@
+package statuskeycardgo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeycardContextCardRemoved(t *testing.T) {
+	emu, _ := newTestEmulator(t)
+
+	kc, err := startKeycardContext(emu.NewTransport, readerFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer kc.stop()
+
+	select {
+	case <-kc.connected:
+	case <-time.After(testTimeout):
+		t.Fatal("not connected")
+	}
+
+	if _, err := kc.selectApplet(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-kc.removed:
+		t.Fatal("removed signalled with the card in the reader")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := emu.Remove(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-kc.removed:
+	case <-time.After(testTimeout):
+		t.Fatal("removal not signalled")
+	}
+
+	_, err = kc.selectApplet()
+
+	if _, ok := err.(*TransportError); !ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestFlowCardRemovedWhilePaused(t *testing.T) {
+	signals := recordSignals(t)
+	emu, card := newTestEmulator(t)
+	f := newTestFlowWithTransport(t, emu.NewTransport)
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	status := waitForSignal(t, signals, EnterPIN)
+
+	if err := emu.Remove(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	status = waitForSignal(t, signals, CardRemoved)
+
+	if status[PendingAct] != EnterPIN || status[InstanceUID] != card.InstanceUID() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	if err := f.Resume(FlowParams{PIN: testPIN}); err == nil {
+		t.Fatal("resumed without card")
+	}
+
+	if err := emu.Insert(testReader, card); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, CardInserted)
+	waitForSignal(t, signals, EnterPIN)
+
+	if err := f.Resume(FlowParams{PIN: testPIN, NewPIN: "654321"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := waitForSignal(t, signals, FlowResult); result[ErrorKey] != nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestFlowCardRemovedWhileRunningCommand(t *testing.T) {
+	signals := recordSignals(t)
+	emu, card := newTestEmulator(t)
+	f := newTestFlowWithTransport(t, slowTransports(emu.NewTransport, 50*time.Millisecond))
+
+	if err := f.Start(ChangePIN, FlowParams{PIN: testPIN, NewPIN: "654321"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the flow is still pairing or opening the secure channel
+	time.Sleep(150 * time.Millisecond)
+
+	if err := emu.Remove(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, InsertCard)
+
+	if err := emu.Insert(testReader, card); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := waitForSignal(t, signals, FlowResult); result[ErrorKey] != nil {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
@@ -3,13 +3,27 @@ package statuskeycardgo
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 
 	"github.com/status-im/status-keycard-go/signal"
 )
 
+type mockedSignal struct {
+	signalType string
+	status     FlowStatus
+	flowID     uint64
+}
+
+// MockedKeycardFlow holds mu for the whole handling of a call, signals are
+// queued meanwhile and delivered once it is released.
 type MockedKeycardFlow struct {
+	seq      uint64
+	mu       sync.Mutex
+	pending  []mockedSignal
 	flowType FlowType
 	state    runState
 	params   FlowParams
@@ -24,6 +38,16 @@ type MockedKeycardFlow struct {
 
 	insertedKeycard       *MockedKeycard
 	insertedKeycardHelper *MockedKeycard // used to generate necessary responses in case a mocked keycard is not configured
+
+	monitoring bool
+
+	started    bool
+	pendingAct string
+	lastStatus FlowStatus
+
+	queue     []queuedFlow
+	requestID string
+	flowID    uint64
 }
 
 func NewMockedFlow(storageDir string) (*MockedKeycardFlow, error) {
@@ -49,24 +73,127 @@ func NewMockedFlow(storageDir string) (*MockedKeycardFlow, error) {
 }
 
 func (mkf *MockedKeycardFlow) Start(flowType FlowType, params FlowParams) error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
 	if mkf.state != Idle {
 		return errors.New("already running")
 	}
 
+	if params == nil {
+		params = FlowParams{}
+	}
+
+	if err := validateParams(flowType, params); err != nil {
+		return err
+	}
+
+	mkf.run(flowType, params, "")
+
+	return nil
+}
+
+func (mkf *MockedKeycardFlow) run(flowType FlowType, params FlowParams, requestID string) {
+	mkf.prepare(flowType, params, requestID)
+	go mkf.runFlow()
+}
+
+func (mkf *MockedKeycardFlow) prepare(flowType FlowType, params FlowParams, requestID string) {
 	mkf.flowType = flowType
 	mkf.params = params
+	mkf.requestID = requestID
 	mkf.state = Running
+	mkf.started = true
+	mkf.pendingAct = ""
+	mkf.lastStatus = nil
+	mkf.flowID = atomic.AddUint64(&lastFlowID, 1)
+}
 
-	go mkf.runFlow()
+func (mkf *MockedKeycardFlow) Enqueue(requestID string, flowType FlowType, params FlowParams) error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	if requestID == "" {
+		return errors.New("missing request id")
+	}
+
+	if mkf.hasRequest(requestID) {
+		return errors.New("duplicate request id")
+	}
+
+	if params == nil {
+		params = FlowParams{}
+	}
+
+	if err := validateParams(flowType, params); err != nil {
+		return err
+	}
+
+	mkf.queue = append(mkf.queue, queuedFlow{requestID, flowType, params})
 
 	return nil
 }
 
+func (mkf *MockedKeycardFlow) CancelRequest(requestID string) error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	if requestID != "" && requestID == mkf.requestID && mkf.state != Idle {
+		mkf.cancel()
+		return nil
+	}
+
+	for i, q := range mkf.queue {
+		if q.requestID == requestID {
+			mkf.queue = append(mkf.queue[:i], mkf.queue[i+1:]...)
+			return nil
+		}
+	}
+
+	return errors.New("unknown request id")
+}
+
+func (mkf *MockedKeycardFlow) hasRequest(requestID string) bool {
+	if requestID == mkf.requestID && mkf.state != Idle {
+		return true
+	}
+
+	for _, q := range mkf.queue {
+		if q.requestID == requestID {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (mkf *MockedKeycardFlow) Resume(params FlowParams) error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	return mkf.resume(params)
+}
+
+func (mkf *MockedKeycardFlow) ResumeFlow(flowID uint64, params FlowParams) error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	if err := mkf.checkFlowID(flowID); err != nil {
+		return err
+	}
+
+	return mkf.resume(params)
+}
+
+func (mkf *MockedKeycardFlow) resume(params FlowParams) error {
 	if mkf.state != Paused {
 		return errors.New("only paused flows can be resumed")
 	}
 
+	if err := validateParams(mkf.flowType, params); err != nil {
+		return err
+	}
+
 	if mkf.params == nil {
 		mkf.params = FlowParams{}
 	}
@@ -75,25 +202,121 @@ func (mkf *MockedKeycardFlow) Resume(params FlowParams) error {
 		mkf.params[k] = v
 	}
 
+	mkf.pendingAct = ""
+
 	go mkf.runFlow()
 
 	return nil
 }
 
 func (mkf *MockedKeycardFlow) Cancel() error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
 
 	if mkf.state == Idle {
 		return errors.New("cannot cancel idle flow")
 	}
 
+	mkf.cancel()
+
+	return nil
+}
+
+func (mkf *MockedKeycardFlow) CancelFlow(flowID uint64) error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	if err := mkf.checkFlowID(flowID); err != nil {
+		return err
+	}
+
+	mkf.cancel()
+
+	return nil
+}
+
+func (mkf *MockedKeycardFlow) cancel() {
 	mkf.state = Idle
 	mkf.params = nil
+	mkf.pendingAct = ""
+}
+
+func (mkf *MockedKeycardFlow) checkFlowID(flowID uint64) error {
+	if mkf.state == Idle || mkf.flowID != flowID {
+		return fmt.Errorf("flow %d is not running", flowID)
+	}
+
+	return nil
+}
+
+func (mkf *MockedKeycardFlow) GetState() *FlowState {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	state := &FlowState{
+		State:         mkf.state.String(),
+		RequestID:     mkf.requestID,
+		PendingAction: mkf.pendingAct,
+		Status:        copyStatus(mkf.lastStatus),
+	}
+
+	for _, q := range mkf.queue {
+		state.Queue = append(state.Queue, q.requestID)
+	}
+
+	if mkf.started {
+		flowType := mkf.flowType
+		state.FlowType = &flowType
+		state.FlowID = mkf.flowID
+	}
+
+	if mkf.currentReaderState == KeycardInserted {
+		state.ReaderName = mockedReaderName
+	}
+
+	return state
+}
+
+func (mkf *MockedKeycardFlow) StartMonitor() error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	if mkf.monitoring {
+		return errors.New("monitor already running")
+	}
+
+	mkf.monitoring = true
+
+	if mkf.currentReaderState != NoReader {
+		mkf.sendMonitorSignal(MonitorReaderAdded)
+	}
+
+	if mkf.currentReaderState == KeycardInserted && mkf.insertedKeycard != nil {
+		mkf.sendMonitorSignal(MonitorCardPresent)
+	}
+
+	return nil
+}
+
+func (mkf *MockedKeycardFlow) StopMonitor() error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	if !mkf.monitoring {
+		return errors.New("monitor not running")
+	}
+
+	mkf.monitoring = false
 
 	return nil
 }
 
 func (mkf *MockedKeycardFlow) ReaderPluggedIn() error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
 	mkf.currentReaderState = NoKeycard
+	mkf.sendMonitorSignal(MonitorReaderAdded)
 
 	if mkf.state == Running {
 		go mkf.runFlow()
@@ -103,7 +326,15 @@ func (mkf *MockedKeycardFlow) ReaderPluggedIn() error {
 }
 
 func (mkf *MockedKeycardFlow) ReaderUnplugged() error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
+	if mkf.currentReaderState == KeycardInserted {
+		mkf.sendMonitorSignal(MonitorCardRemoved)
+	}
+
 	mkf.currentReaderState = NoReader
+	mkf.sendMonitorSignal(MonitorReaderRemoved)
 
 	go mkf.runFlow()
 
@@ -111,6 +342,9 @@ func (mkf *MockedKeycardFlow) ReaderUnplugged() error {
 }
 
 func (mkf *MockedKeycardFlow) KeycardInserted(cardIndex int) error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
 	if mkf.registeredKeycards == nil || mkf.registeredKeycardHelpers == nil ||
 		len(mkf.registeredKeycards) == 0 || len(mkf.registeredKeycardHelpers) == 0 ||
 		mkf.registeredKeycards[cardIndex] == nil || mkf.registeredKeycardHelpers[cardIndex] == nil {
@@ -121,6 +355,7 @@ func (mkf *MockedKeycardFlow) KeycardInserted(cardIndex int) error {
 
 	mkf.insertedKeycard = mkf.registeredKeycards[cardIndex]
 	mkf.insertedKeycardHelper = mkf.registeredKeycardHelpers[cardIndex]
+	mkf.sendMonitorSignal(MonitorCardPresent)
 
 	if mkf.state == Running {
 		go mkf.runFlow()
@@ -130,10 +365,14 @@ func (mkf *MockedKeycardFlow) KeycardInserted(cardIndex int) error {
 }
 
 func (mkf *MockedKeycardFlow) KeycardRemoved() error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
 	mkf.currentReaderState = NoKeycard
 
 	mkf.insertedKeycard = nil
 	mkf.insertedKeycardHelper = nil
+	mkf.sendMonitorSignal(MonitorCardRemoved)
 
 	if mkf.state == Running {
 		go mkf.runFlow()
@@ -144,6 +383,9 @@ func (mkf *MockedKeycardFlow) KeycardRemoved() error {
 
 func (mkf *MockedKeycardFlow) RegisterKeycard(cardIndex int, readerState MockedReaderState, keycardState MockedKeycardState,
 	keycard *MockedKeycard, keycardHelper *MockedKeycard) error {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+
 	mkf.state = Idle
 	mkf.params = nil
 
@@ -186,13 +428,99 @@ func (mkf *MockedKeycardFlow) RegisterKeycard(cardIndex int, readerState MockedR
 	return mkf.storeRegisteredKeycards()
 }
 
+func (mkf *MockedKeycardFlow) send(signalType string, status FlowStatus) {
+	flowID := mkf.flowID
+
+	switch signalType {
+	case MonitorReaderAdded, MonitorReaderRemoved, MonitorCardPresent, MonitorCardRemoved:
+		flowID = 0
+	case FlowResult:
+		if mkf.requestID != "" {
+			status = copyStatus(status)
+			status[RequestID] = mkf.requestID
+		}
+
+		mkf.pendingAct = ""
+		mkf.lastStatus = copyStatus(status)
+	default:
+		mkf.pendingAct = signalType
+		mkf.lastStatus = copyStatus(status)
+	}
+
+	mkf.pending = append(mkf.pending, mockedSignal{signalType, status, flowID})
+}
+
+func (mkf *MockedKeycardFlow) unlock() {
+	pending := mkf.pending
+	next := mkf.state == Idle && mkf.dequeue()
+	mkf.pending = nil
+	mkf.mu.Unlock()
+
+	for _, s := range pending {
+		signal.SendEnvelope(&signal.Envelope{
+			Type:   s.signalType,
+			Event:  s.status,
+			FlowID: s.flowID,
+			Seq:    atomic.AddUint64(&mkf.seq, 1),
+		})
+	}
+
+	// the next enqueued flow is taken under the lock, so that a Start cannot
+	// jump ahead of it, but only runs once the result of the previous one is
+	// delivered
+	if next {
+		go mkf.runFlow()
+	}
+}
+
+func (mkf *MockedKeycardFlow) dequeue() bool {
+	if len(mkf.queue) == 0 {
+		return false
+	}
+
+	q := mkf.queue[0]
+	mkf.queue = mkf.queue[1:]
+	mkf.prepare(q.flowType, q.params, q.requestID)
+
+	return true
+}
+
+func (mkf *MockedKeycardFlow) sendMonitorSignal(signalType string) {
+	if !mkf.monitoring {
+		return
+	}
+
+	status := FlowStatus{ReaderName: mockedReaderName}
+
+	if signalType == MonitorCardPresent {
+		if mkf.insertedKeycard.NotStatusKeycard {
+			status[ErrorKey] = ErrorNotAKeycard
+		} else {
+			keycardStoresKeys := mkf.insertedKeycard.InstanceUID != "" && mkf.insertedKeycard.KeyUID != ""
+			status[AppInfo] = ApplicationInfo{
+				Initialized:    keycardStoresKeys,
+				InstanceUID:    hexString(mkf.insertedKeycard.InstanceUID),
+				Version:        123,
+				AvailableSlots: mkf.insertedKeycard.FreePairingSlots,
+				KeyUID:         hexString(mkf.insertedKeycard.KeyUID),
+			}
+		}
+	}
+
+	mkf.send(signalType, status)
+}
+
 func (mkf *MockedKeycardFlow) runFlow() {
+	mkf.mu.Lock()
+	defer mkf.unlock()
+	defer mkf.recoverFlow()
+
 	switch mkf.currentReaderState {
 	case NoReader:
-		signal.Send(FlowResult, FlowStatus{ErrorKey: ErrorNoReader})
+		mkf.send(FlowResult, FlowStatus{ErrorKey: ErrorNoReader})
 		return
 	case NoKeycard:
-		signal.Send(InsertCard, FlowStatus{ErrorKey: ErrorConnection})
+		mkf.send(InsertCard, FlowStatus{ErrorKey: ErrorConnection})
 		return
 	default:
 		switch mkf.flowType {
@@ -227,6 +555,19 @@ func (mkf *MockedKeycardFlow) runFlow() {
 	mkf.storeRegisteredKeycards()
 }
 
+// recoverFlow ends the flow with an internal error if handling it panicked,
+// the result is delivered by the deferred unlock.
+func (mkf *MockedKeycardFlow) recoverFlow() {
+	if r := recover(); r != nil {
+		p := recoveredPanic(r)
+		l("mocked flow panicked: %v\n%s", p.value, p.stack)
+
+		mkf.state = Idle
+		mkf.params = nil
+		mkf.send(FlowResult, p.status())
+	}
+}
+
 func (mkf *MockedKeycardFlow) storeRegisteredKeycards() error {
 	data, err := json.Marshal(struct {
 		RegisteredKeycards       map[int]*MockedKeycard
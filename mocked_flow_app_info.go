@@ -1,9 +1,5 @@
 package statuskeycardgo
 
-import (
-	"github.com/status-im/status-keycard-go/signal"
-)
-
 func (mkf *MockedKeycardFlow) handleGetAppInfoFlow() {
 	flowStatus := FlowStatus{}
 
@@ -13,7 +9,7 @@ func (mkf *MockedKeycardFlow) handleGetAppInfoFlow() {
 		flowStatus[KeyUID] = ""
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -26,7 +22,7 @@ func (mkf *MockedKeycardFlow) handleGetAppInfoFlow() {
 		flowStatus[ErrorKey] = ErrorNoKeys
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -46,7 +42,7 @@ func (mkf *MockedKeycardFlow) handleGetAppInfoFlow() {
 	if factoryReset {
 		mkf.state = Idle
 		*mkf.insertedKeycard = MockedKeycard{}
-		signal.Send(FlowResult, FlowStatus{
+		mkf.send(FlowResult, FlowStatus{
 			ErrorKey: ErrorOK,
 			Paired:   false,
 			AppInfo: ApplicationInfo{
@@ -72,7 +68,7 @@ func (mkf *MockedKeycardFlow) handleGetAppInfoFlow() {
 			KeyUID:         hexString(mkf.insertedKeycard.KeyUID),
 		}
 		mkf.state = Idle
-		signal.Send(FlowResult, flowStatus)
+		mkf.send(FlowResult, flowStatus)
 		return
 	}
 
@@ -80,5 +76,5 @@ func (mkf *MockedKeycardFlow) handleGetAppInfoFlow() {
 	flowStatus[InstanceUID] = mkf.insertedKeycard.InstanceUID
 	flowStatus[KeyUID] = mkf.insertedKeycard.KeyUID
 	mkf.state = Paused
-	signal.Send(EnterPIN, flowStatus)
+	mkf.send(EnterPIN, flowStatus)
 }
@@ -1,9 +1,5 @@
 package statuskeycardgo
 
-import (
-	"github.com/status-im/status-keycard-go/signal"
-)
-
 func (mkf *MockedKeycardFlow) handleChangePinFlow() {
 	flowStatus := FlowStatus{}
 
@@ -13,7 +9,7 @@ func (mkf *MockedKeycardFlow) handleChangePinFlow() {
 		flowStatus[KeyUID] = ""
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -26,7 +22,7 @@ func (mkf *MockedKeycardFlow) handleChangePinFlow() {
 		flowStatus[ErrorKey] = ErrorRequireInit
 		flowStatus[FreeSlots] = mkf.insertedKeycard.FreePairingSlots
 		mkf.state = Paused
-		signal.Send(EnterNewPIN, flowStatus)
+		mkf.send(EnterNewPIN, flowStatus)
 		return
 	}
 
@@ -34,7 +30,7 @@ func (mkf *MockedKeycardFlow) handleChangePinFlow() {
 		flowStatus[ErrorKey] = FreeSlots
 		flowStatus[FreeSlots] = mkf.insertedKeycard.FreePairingSlots
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -112,7 +108,7 @@ func (mkf *MockedKeycardFlow) handleChangePinFlow() {
 			mkf.insertedKeycard.PinRetries = maxPINRetries
 			mkf.insertedKeycard.PukRetries = maxPUKRetries
 			mkf.insertedKeycard.Pin = enteredPIN
-			signal.Send(FlowResult, flowStatus)
+			mkf.send(FlowResult, flowStatus)
 			return
 		}
 	}
@@ -121,5 +117,5 @@ func (mkf *MockedKeycardFlow) handleChangePinFlow() {
 	flowStatus[PINRetries] = mkf.insertedKeycard.PinRetries
 	flowStatus[PUKRetries] = mkf.insertedKeycard.PukRetries
 	mkf.state = Paused
-	signal.Send(finalType, flowStatus)
+	mkf.send(finalType, flowStatus)
 }
@@ -1,9 +1,5 @@
 package statuskeycardgo
 
-import (
-	"github.com/status-im/status-keycard-go/signal"
-)
-
 func (mkf *MockedKeycardFlow) handleChangePukFlow() {
 	flowStatus := FlowStatus{}
 
@@ -13,7 +9,7 @@ func (mkf *MockedKeycardFlow) handleChangePukFlow() {
 		flowStatus[KeyUID] = ""
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -26,7 +22,7 @@ func (mkf *MockedKeycardFlow) handleChangePukFlow() {
 		flowStatus[ErrorKey] = ErrorRequireInit
 		flowStatus[FreeSlots] = mkf.insertedKeycard.FreePairingSlots
 		mkf.state = Paused
-		signal.Send(EnterNewPIN, flowStatus)
+		mkf.send(EnterNewPIN, flowStatus)
 		return
 	}
 
@@ -34,7 +30,7 @@ func (mkf *MockedKeycardFlow) handleChangePukFlow() {
 		flowStatus[ErrorKey] = FreeSlots
 		flowStatus[FreeSlots] = mkf.insertedKeycard.FreePairingSlots
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -116,7 +112,7 @@ func (mkf *MockedKeycardFlow) handleChangePukFlow() {
 			mkf.insertedKeycard.PukRetries = maxPUKRetries
 			mkf.insertedKeycard.Puk = enteredPUK
 			mkf.state = Idle
-			signal.Send(FlowResult, flowStatus)
+			mkf.send(FlowResult, flowStatus)
 			return
 		}
 	}
@@ -125,5 +121,5 @@ func (mkf *MockedKeycardFlow) handleChangePukFlow() {
 	flowStatus[PINRetries] = mkf.insertedKeycard.PinRetries
 	flowStatus[PUKRetries] = mkf.insertedKeycard.PukRetries
 	mkf.state = Paused
-	signal.Send(finalType, flowStatus)
+	mkf.send(finalType, flowStatus)
 }
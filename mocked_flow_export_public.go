@@ -4,8 +4,6 @@ import (
 	"math/rand"
 	"strconv"
 	"strings"
-
-	"github.com/status-im/status-keycard-go/signal"
 )
 
 func (mkf *MockedKeycardFlow) handleExportPublicFlow() {
@@ -17,7 +15,7 @@ func (mkf *MockedKeycardFlow) handleExportPublicFlow() {
 		flowStatus[KeyUID] = ""
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -30,7 +28,7 @@ func (mkf *MockedKeycardFlow) handleExportPublicFlow() {
 		flowStatus[ErrorKey] = ErrorNoKeys
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -168,7 +166,7 @@ func (mkf *MockedKeycardFlow) handleExportPublicFlow() {
 		}
 
 		mkf.state = Idle
-		signal.Send(FlowResult, flowStatus)
+		mkf.send(FlowResult, flowStatus)
 		return
 	}
 
@@ -176,5 +174,5 @@ func (mkf *MockedKeycardFlow) handleExportPublicFlow() {
 	flowStatus[PINRetries] = mkf.insertedKeycard.PinRetries
 	flowStatus[PUKRetries] = mkf.insertedKeycard.PukRetries
 	mkf.state = Paused
-	signal.Send(finalType, flowStatus)
+	mkf.send(finalType, flowStatus)
 }
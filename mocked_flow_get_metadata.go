@@ -4,8 +4,6 @@ import (
 	"math/rand"
 	"strconv"
 	"strings"
-
-	"github.com/status-im/status-keycard-go/signal"
 )
 
 func (mkf *MockedKeycardFlow) handleGetMetadataFlow() {
@@ -17,13 +15,13 @@ func (mkf *MockedKeycardFlow) handleGetMetadataFlow() {
 		flowStatus[KeyUID] = ""
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
 	if mkf.insertedKeycard.InstanceUID == "" || mkf.insertedKeycard.KeyUID == "" {
 		mkf.state = Idle
-		signal.Send(FlowResult, FlowStatus{ErrorKey: ErrorNoKeys})
+		mkf.send(FlowResult, FlowStatus{ErrorKey: ErrorNoKeys})
 		return
 	}
 
@@ -37,7 +35,7 @@ func (mkf *MockedKeycardFlow) handleGetMetadataFlow() {
 			flowStatus[ErrorKey] = FreeSlots
 			flowStatus[FreeSlots] = mkf.insertedKeycard.FreePairingSlots
 			mkf.state = Paused
-			signal.Send(SwapCard, flowStatus)
+			mkf.send(SwapCard, flowStatus)
 			return
 		}
 
@@ -118,7 +116,7 @@ func (mkf *MockedKeycardFlow) handleGetMetadataFlow() {
 			flowStatus[ErrorKey] = ""
 			flowStatus[CardMeta] = mkf.insertedKeycard.Metadata
 			mkf.state = Idle
-			signal.Send(FlowResult, flowStatus)
+			mkf.send(FlowResult, flowStatus)
 			return
 		}
 
@@ -126,7 +124,7 @@ func (mkf *MockedKeycardFlow) handleGetMetadataFlow() {
 		flowStatus[PINRetries] = mkf.insertedKeycard.PinRetries
 		flowStatus[PUKRetries] = mkf.insertedKeycard.PukRetries
 		mkf.state = Paused
-		signal.Send(finalType, flowStatus)
+		mkf.send(finalType, flowStatus)
 		return
 	}
 
@@ -141,5 +139,5 @@ func (mkf *MockedKeycardFlow) handleGetMetadataFlow() {
 
 	flowStatus[CardMeta] = pubMetadata
 	mkf.state = Idle
-	signal.Send(FlowResult, flowStatus)
+	mkf.send(FlowResult, flowStatus)
 }
@@ -3,8 +3,6 @@ package statuskeycardgo
 import (
 	"math/rand"
 	"strings"
-
-	"github.com/status-im/status-keycard-go/signal"
 )
 
 func (mkf *MockedKeycardFlow) handleLoadAccountFlow() {
@@ -16,7 +14,7 @@ func (mkf *MockedKeycardFlow) handleLoadAccountFlow() {
 		flowStatus[KeyUID] = ""
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -75,7 +73,7 @@ func (mkf *MockedKeycardFlow) handleLoadAccountFlow() {
 		flowStatus[ErrorKey] = ErrorHasKeys
 		flowStatus[FreeSlots] = mkf.insertedKeycard.FreePairingSlots
 		mkf.state = Paused
-		signal.Send(finalType, flowStatus)
+		mkf.send(finalType, flowStatus)
 		return
 	}
 
@@ -102,7 +100,7 @@ func (mkf *MockedKeycardFlow) handleLoadAccountFlow() {
 			flowStatus[PINRetries] = mkf.insertedKeycard.PinRetries
 			flowStatus[PUKRetries] = mkf.insertedKeycard.PukRetries
 			mkf.state = Paused
-			signal.Send(finalType, flowStatus)
+			mkf.send(finalType, flowStatus)
 			return
 		} else {
 			realMnemonicLength := len(strings.Split(enteredMnemonic, " "))
@@ -122,7 +120,7 @@ func (mkf *MockedKeycardFlow) handleLoadAccountFlow() {
 				flowStatus[InstanceUID] = mkf.insertedKeycard.InstanceUID
 				flowStatus[KeyUID] = mkf.insertedKeycard.KeyUID
 				mkf.state = Idle
-				signal.Send(finalType, flowStatus)
+				mkf.send(finalType, flowStatus)
 				return
 			}
 		}
@@ -131,5 +129,5 @@ func (mkf *MockedKeycardFlow) handleLoadAccountFlow() {
 	finalType = EnterNewPIN
 	flowStatus[ErrorKey] = ErrorRequireInit
 	mkf.state = Paused
-	signal.Send(finalType, flowStatus)
+	mkf.send(finalType, flowStatus)
 }
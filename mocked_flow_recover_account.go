@@ -1,9 +1,5 @@
 package statuskeycardgo
 
-import (
-	"github.com/status-im/status-keycard-go/signal"
-)
-
 func (mkf *MockedKeycardFlow) handleRecoverAccountFlow() {
 	flowStatus := FlowStatus{}
 
@@ -13,7 +9,7 @@ func (mkf *MockedKeycardFlow) handleRecoverAccountFlow() {
 		flowStatus[KeyUID] = ""
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -28,7 +24,7 @@ func (mkf *MockedKeycardFlow) handleRecoverAccountFlow() {
 		flowStatus[ErrorKey] = ErrorNoKeys
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(finalType, flowStatus)
+		mkf.send(finalType, flowStatus)
 		return
 	}
 
@@ -102,7 +98,7 @@ func (mkf *MockedKeycardFlow) handleRecoverAccountFlow() {
 		flowStatus[WhisperKey] = mkf.insertedKeycardHelper.ExportedKey[whisperPath]
 		flowStatus[EncKey] = mkf.insertedKeycardHelper.ExportedKey[encryptionPath]
 		mkf.state = Idle
-		signal.Send(FlowResult, flowStatus)
+		mkf.send(FlowResult, flowStatus)
 		return
 	}
 
@@ -110,5 +106,5 @@ func (mkf *MockedKeycardFlow) handleRecoverAccountFlow() {
 	flowStatus[PINRetries] = mkf.insertedKeycard.PinRetries
 	flowStatus[PUKRetries] = mkf.insertedKeycard.PukRetries
 	mkf.state = Paused
-	signal.Send(finalType, flowStatus)
+	mkf.send(finalType, flowStatus)
 }
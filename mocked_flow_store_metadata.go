@@ -3,8 +3,6 @@ package statuskeycardgo
 import (
 	"strconv"
 	"strings"
-
-	"github.com/status-im/status-keycard-go/signal"
 )
 
 func (mkf *MockedKeycardFlow) handleStoreMetadataFlow() {
@@ -16,7 +14,7 @@ func (mkf *MockedKeycardFlow) handleStoreMetadataFlow() {
 		flowStatus[KeyUID] = ""
 		flowStatus[FreeSlots] = 0
 		mkf.state = Paused
-		signal.Send(SwapCard, flowStatus)
+		mkf.send(SwapCard, flowStatus)
 		return
 	}
 
@@ -75,7 +73,7 @@ func (mkf *MockedKeycardFlow) handleStoreMetadataFlow() {
 		}
 
 		mkf.state = Idle
-		signal.Send(finalType, flowStatus)
+		mkf.send(finalType, flowStatus)
 
 		return
 	}
@@ -90,5 +88,5 @@ func (mkf *MockedKeycardFlow) handleStoreMetadataFlow() {
 	flowStatus[PINRetries] = mkf.insertedKeycard.PinRetries
 	flowStatus[PUKRetries] = mkf.insertedKeycard.PukRetries
 	mkf.state = Paused
-	signal.Send(finalType, flowStatus)
+	mkf.send(finalType, flowStatus)
 }
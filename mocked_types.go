@@ -2,6 +2,8 @@ package statuskeycardgo
 
 type MockedReaderState int
 
+const mockedReaderName = "Mocked Reader"
+
 const (
 	NoReader MockedReaderState = iota
 	NoKeycard
@@ -0,0 +1,204 @@
+package statuskeycardgo
+
+import (
+	"errors"
+
+	keycard "github.com/status-im/keycard-go"
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/globalplatform"
+	"github.com/status-im/keycard-go/io"
+)
+
+// readerMonitor watches readers and cards, independently of the flows, and
+// signals every change.
+type readerMonitor struct {
+	flow    *KeycardFlow
+	filter  readerFilter
+	known   []ReaderStatus
+	watcher *readerWatcher
+}
+
+// StartMonitor starts watching the readers allowed by the flow options. The
+// current readers and cards are signalled right away with MonitorReaderAdded
+// and MonitorCardPresent, then every change is signalled as it happens until
+// StopMonitor is called. MonitorCardPresent carries the ApplicationInfo of the
+// card, unless a flow is running: selecting the applet would break the
+// session of the flow.
+func (f *KeycardFlow) StartMonitor() error {
+	f.monitorMu.Lock()
+	defer f.monitorMu.Unlock()
+
+	if f.monitor != nil {
+		return errors.New("monitor already running")
+	}
+
+	m := &readerMonitor{
+		flow:   f,
+		filter: readerFilter{allow: f.readerFilter.allow, deny: f.readerFilter.deny},
+	}
+
+	w, err := startReaderWatcher(f.transport, m.update)
+	if err != nil {
+		return err
+	}
+
+	m.watcher = w
+	f.monitor = m
+
+	return nil
+}
+
+// StopMonitor stops the monitor started by StartMonitor.
+func (f *KeycardFlow) StopMonitor() error {
+	f.monitorMu.Lock()
+	defer f.monitorMu.Unlock()
+
+	if f.monitor == nil {
+		return errors.New("monitor not running")
+	}
+
+	f.monitor.watcher.stop()
+	f.monitor = nil
+
+	return nil
+}
+
+func (m *readerMonitor) update(transport Transport, current []ReaderStatus) bool {
+	current = filterReaderStatus(m.filter, current)
+	m.notify(transport, m.known, current)
+	m.known = current
+
+	return true
+}
+
+func (m *readerMonitor) notify(transport Transport, known []ReaderStatus, current []ReaderStatus) {
+	previous := map[string]bool{}
+	present := map[string]bool{}
+
+	for _, rs := range known {
+		previous[rs.Reader] = rs.CardPresent
+	}
+
+	for _, rs := range current {
+		present[rs.Reader] = true
+	}
+
+	for _, rs := range known {
+		if !present[rs.Reader] {
+			if rs.CardPresent {
+				m.flow.emit(0, MonitorCardRemoved, FlowStatus{ReaderName: rs.Reader})
+			}
+
+			m.flow.emit(0, MonitorReaderRemoved, FlowStatus{ReaderName: rs.Reader})
+		}
+	}
+
+	for _, rs := range current {
+		hadCard, ok := previous[rs.Reader]
+
+		if !ok {
+			m.flow.emit(0, MonitorReaderAdded, FlowStatus{ReaderName: rs.Reader})
+		}
+
+		if rs.CardPresent && !hadCard {
+			m.flow.emit(0, MonitorCardPresent, m.cardStatus(transport, rs.Reader))
+		} else if !rs.CardPresent && hadCard {
+			m.flow.emit(0, MonitorCardRemoved, FlowStatus{ReaderName: rs.Reader})
+		}
+	}
+}
+
+func (m *readerMonitor) cardStatus(transport Transport, reader string) FlowStatus {
+	status := FlowStatus{ReaderName: reader}
+
+	if !m.flow.beginProbe(reader) {
+		return status
+	}
+
+	defer m.flow.endProbe()
+
+	card, err := transport.Connect(reader)
+	if err != nil {
+		l("monitor connection failed %+v", err)
+		status[ErrorKey] = ErrorConnection
+		return status
+	}
+
+	defer card.Disconnect()
+
+	cmdSet := keycard.NewCommandSet(io.NewNormalChannel(card))
+	err = cmdSet.Select()
+
+	if e, ok := err.(*apdu.ErrBadResponse); ok && e.Sw == globalplatform.SwFileNotFound {
+		status[ErrorKey] = ErrorNotAKeycard
+	} else if err != nil {
+		l("monitor select failed %+v", err)
+		status[ErrorKey] = ErrorConnection
+	} else if !cmdSet.ApplicationInfo.Installed {
+		status[ErrorKey] = ErrorNotAKeycard
+	} else {
+		status[AppInfo] = toAppInfo(cmdSet.ApplicationInfo)
+	}
+
+	return status
+}
+
+// beginProbe reserves the card in reader for the monitor, flows started until
+// endProbe wait for it before connecting. It fails if a flow is running or the
+// persistent session uses the reader: selecting the applet would close their
+// secure channel.
+func (f *KeycardFlow) beginProbe(reader string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.state != Idle || (f.session != nil && f.session.kc.reader == reader) {
+		return false
+	}
+
+	f.probing = make(chan (struct{}))
+
+	return true
+}
+
+func (f *KeycardFlow) endProbe() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	close(f.probing)
+	f.probing = nil
+}
+
+// waitProbe waits until the card probed by the monitor, if any, is released.
+func (f *KeycardFlow) waitProbe() {
+	f.mu.Lock()
+	probing := f.probing
+	f.mu.Unlock()
+
+	if probing != nil {
+		<-probing
+	}
+}
+
+func filterReaderStatus(filter readerFilter, status []ReaderStatus) []ReaderStatus {
+	readers := make([]string, len(status))
+
+	for i, rs := range status {
+		readers[i] = rs.Reader
+	}
+
+	allowed := map[string]bool{}
+
+	for _, r := range filter.apply(readers) {
+		allowed[r] = true
+	}
+
+	filtered := []ReaderStatus{}
+
+	for _, rs := range status {
+		if allowed[rs.Reader] {
+			filtered = append(filtered, rs)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,164 @@
+package statuskeycardgo
+
+import (
+	"testing"
+	"time"
+)
+
+// expectMonitorSignal waits for the next monitor signal and checks its type
+// and reader.
+func expectMonitorSignal(t *testing.T, signals chan testSignal, typ string, reader string) FlowStatus {
+	t.Helper()
+
+	timeout := time.After(testTimeout)
+
+	for {
+		select {
+		case s := <-signals:
+			switch s.Type {
+			case MonitorReaderAdded, MonitorReaderRemoved, MonitorCardPresent, MonitorCardRemoved:
+			default:
+				continue
+			}
+
+			if s.Type != typ || s.Event[ReaderName] != reader {
+				t.Fatalf("expected %s for %s, got %s %+v", typ, reader, s.Type, s.Event)
+			}
+
+			return s.Event
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", typ)
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	signals := recordSignals(t)
+	emu, card := newTestEmulator(t)
+	f := newTestFlowWithTransport(t, emu.NewTransport)
+
+	if err := f.StopMonitor(); err == nil {
+		t.Fatal("stopped a monitor which is not running")
+	}
+
+	if err := f.StartMonitor(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.StartMonitor(); err == nil {
+		t.Fatal("started the monitor twice")
+	}
+
+	expectMonitorSignal(t, signals, MonitorReaderAdded, testReader)
+	status := expectMonitorSignal(t, signals, MonitorCardPresent, testReader)
+
+	if info, ok := status[AppInfo].(map[string]interface{}); !ok || info["instanceUID"] != card.InstanceUID() {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	if err := emu.Remove(testReader); err != nil {
+		t.Fatal(err)
+	}
+
+	expectMonitorSignal(t, signals, MonitorCardRemoved, testReader)
+
+	if err := emu.PlugReader("other"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectMonitorSignal(t, signals, MonitorReaderAdded, "other")
+
+	if err := emu.Insert("other", card); err != nil {
+		t.Fatal(err)
+	}
+
+	expectMonitorSignal(t, signals, MonitorCardPresent, "other")
+
+	if err := emu.UnplugReader("other"); err != nil {
+		t.Fatal(err)
+	}
+
+	expectMonitorSignal(t, signals, MonitorCardRemoved, "other")
+	expectMonitorSignal(t, signals, MonitorReaderRemoved, "other")
+
+	if err := f.StopMonitor(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := emu.Insert(testReader, card); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case s := <-signals:
+		t.Fatalf("signal %s after StopMonitor", s.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMonitorDoesNotProbeDuringFlows(t *testing.T) {
+	signals := recordSignals(t)
+	f := newTestFlow(t)
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterPIN)
+
+	if err := f.StartMonitor(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.StopMonitor()
+
+	expectMonitorSignal(t, signals, MonitorReaderAdded, testReader)
+
+	if status := expectMonitorSignal(t, signals, MonitorCardPresent, testReader); status[AppInfo] != nil {
+		t.Fatalf("card probed during a flow %+v", status)
+	}
+
+	// the secure channel of the flow is still open
+	if err := f.Resume(FlowParams{PIN: testPIN}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitForSignal(t, signals, EnterNewPIN)
+	waitForIdle(t, f.GetState, f.Cancel)
+}
+
+func TestFlowWaitsForProbe(t *testing.T) {
+	signals := recordSignals(t)
+	f := newTestFlow(t)
+
+	if !f.beginProbe(testReader) {
+		t.Fatal("cannot probe an idle flow")
+	}
+
+	if err := f.Start(ChangePIN, FlowParams{}); err != nil {
+		f.endProbe()
+		t.Fatal(err)
+	}
+
+	if f.beginProbe(testReader) {
+		t.Fatal("probed while a flow is running")
+	}
+
+	select {
+	case s := <-signals:
+		f.endProbe()
+		t.Fatalf("flow signalled %s during the probe", s.Type)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	f.endProbe()
+
+	waitForSignal(t, signals, EnterPIN)
+	waitForIdle(t, f.GetState, f.Cancel)
+
+	if !f.beginProbe(testReader) {
+		t.Fatal("cannot probe once the flow is done")
+	}
+
+	f.endProbe()
+}
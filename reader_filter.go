@@ -0,0 +1,49 @@
+package statuskeycardgo
+
+import "path"
+
+type readerFilter struct {
+	allow     []string
+	deny      []string
+	requested string
+}
+
+func matchReader(pattern string, reader string) bool {
+	if ok, err := path.Match(pattern, reader); err == nil && ok {
+		return true
+	}
+
+	return pattern == reader
+}
+
+func matchAnyReader(patterns []string, reader string) bool {
+	for _, p := range patterns {
+		if matchReader(p, reader) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rf readerFilter) apply(readers []string) []string {
+	res := []string{}
+
+	for _, r := range readers {
+		if len(rf.allow) > 0 && !matchAnyReader(rf.allow, r) {
+			continue
+		}
+
+		if matchAnyReader(rf.deny, r) {
+			continue
+		}
+
+		if rf.requested != "" && !matchReader(rf.requested, r) {
+			continue
+		}
+
+		res = append(res, r)
+	}
+
+	return res
+}
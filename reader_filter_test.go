@@ -0,0 +1,74 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestReaderFilter(t *testing.T) {
+	readers := []string{"ACS ACR1252 0", "ACS ACR1252 1", "Yubico YubiKey", "Broken [reader"}
+
+	tests := []struct {
+		name     string
+		filter   readerFilter
+		expected []string
+	}{
+		{"none", readerFilter{}, readers},
+		{"allow name", readerFilter{allow: []string{"Yubico YubiKey"}}, []string{"Yubico YubiKey"}},
+		{"allow glob", readerFilter{allow: []string{"ACS*"}}, []string{"ACS ACR1252 0", "ACS ACR1252 1"}},
+		{"allow several", readerFilter{allow: []string{"ACS * 1", "Yubico*"}}, []string{"ACS ACR1252 1", "Yubico YubiKey"}},
+		{"allow nothing matching", readerFilter{allow: []string{"Gemalto*"}}, []string{}},
+		{"deny glob", readerFilter{deny: []string{"ACS*"}}, []string{"Yubico YubiKey", "Broken [reader"}},
+		{"deny over allow", readerFilter{allow: []string{"ACS*"}, deny: []string{"*1"}}, []string{"ACS ACR1252 0"}},
+		{"deny all allowed", readerFilter{allow: []string{"Yubico*"}, deny: []string{"Yubico YubiKey"}}, []string{}},
+		{"invalid pattern", readerFilter{allow: []string{"Broken [*"}}, []string{}},
+		{"invalid pattern equal", readerFilter{allow: []string{"Broken [reader"}}, []string{"Broken [reader"}},
+		{"invalid deny equal", readerFilter{deny: []string{"Broken [reader"}}, readers[:3]},
+		{"requested", readerFilter{requested: "ACS ACR1252 1"}, []string{"ACS ACR1252 1"}},
+		{"requested glob", readerFilter{requested: "*0"}, []string{"ACS ACR1252 0"}},
+		{"requested denied", readerFilter{deny: []string{"ACS*"}, requested: "ACS ACR1252 1"}, []string{}},
+		{"requested not allowed", readerFilter{allow: []string{"Yubico*"}, requested: "ACS*"}, []string{}},
+	}
+
+	for _, test := range tests {
+		if res := test.filter.apply(readers); !reflect.DeepEqual(res, test.expected) {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, res)
+		}
+	}
+}
+
+func TestFlowReaderAllowlist(t *testing.T) {
+	emu := NewEmulator("Other", "ACS ACR1252 0")
+
+	for _, reader := range []string{"Other", "ACS ACR1252 0"} {
+		if err := emu.Insert(reader, newTestCard(t)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := newTestFlowWithTransport(t, emu.NewTransport, WithReaderAllowlist("ACS*"))
+
+	result, err := f.RunFlow(context.Background(), GetAppInfo, FlowParams{PIN: testPIN}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result[ReaderName] != "ACS ACR1252 0" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
+
+func TestFlowReaderDenylist(t *testing.T) {
+	emu, _ := newTestEmulator(t)
+	f := newTestFlowWithTransport(t, emu.NewTransport, WithReaderDenylist("*"))
+
+	result, err := f.RunFlow(context.Background(), GetAppInfo, FlowParams{}, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result[ErrorKey] != ErrorNoReader {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
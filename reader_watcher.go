@@ -0,0 +1,148 @@
+package statuskeycardgo
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// readerWatcher waits for reader changes on a transport of its own, so that it
+// does not interfere with the connections of the flow, and hands every new
+// state to onChange, along with the transport, until it is stopped or onChange
+// returns false.
+type readerWatcher struct {
+	onChange  func(transport Transport, current []ReaderStatus) bool
+	mu        sync.Mutex
+	transport Transport
+	stopping  bool
+	released  bool
+	started   chan error
+	done      chan struct{}
+}
+
+func startReaderWatcher(newTransport TransportFactory, onChange func(transport Transport, current []ReaderStatus) bool) (*readerWatcher, error) {
+	w := &readerWatcher{
+		onChange: onChange,
+		started:  make(chan error),
+		done:     make(chan struct{}),
+	}
+
+	go w.run(newTransport)
+
+	if err := <-w.started; err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *readerWatcher) run(newTransport TransportFactory) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(w.done)
+
+	started := false
+
+	// a panic only stops the watcher, the flows and the host keep running
+	defer func() {
+		if r := recover(); r != nil {
+			p := recoveredPanic(r)
+			l("reader watcher panicked: %v\n%s", p.value, p.stack)
+
+			if !started {
+				w.started <- p
+			}
+		}
+	}()
+
+	transport, err := newTransport()
+	if err != nil {
+		w.started <- err
+		return
+	}
+
+	if wt, ok := transport.(wrappingTransport); ok {
+		transport = wt.unwrap()
+	}
+
+	w.mu.Lock()
+	w.transport = transport
+	w.mu.Unlock()
+
+	defer w.release()
+
+	started = true
+	w.started <- nil
+
+	var known []ReaderStatus
+
+	for {
+		current, err := transport.WaitForChange(known)
+
+		if w.isStopping() {
+			return
+		}
+
+		if err != nil {
+			l("watching readers failed %+v", err)
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+
+		if !w.onChange(transport, current) {
+			return
+		}
+
+		known = current
+	}
+}
+
+func (w *readerWatcher) stop() {
+	w.mu.Lock()
+	w.stopping = true
+	w.mu.Unlock()
+
+	// the watcher might be between two waits, keep cancelling until it is
+	// done
+	for {
+		select {
+		case <-w.done:
+			return
+		default:
+		}
+
+		w.cancel()
+
+		select {
+		case <-w.done:
+			return
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// cancel cancels the pending wait of the watcher, unless the transport is being
+// released.
+func (w *readerWatcher) cancel() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.released {
+		_ = w.transport.Cancel()
+	}
+}
+
+func (w *readerWatcher) release() {
+	w.mu.Lock()
+	w.released = true
+	w.mu.Unlock()
+
+	_ = w.transport.Release()
+}
+
+func (w *readerWatcher) isStopping() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.stopping
+}
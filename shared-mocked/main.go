@@ -6,6 +6,7 @@ import "C"
 
 import (
 	"encoding/json"
+	"errors"
 	"unsafe"
 
 	skg "github.com/status-im/status-keycard-go"
@@ -17,21 +18,20 @@ func main() {}
 var globalFlow *skg.MockedKeycardFlow
 
 func retErr(err error) *C.char {
+	var paramErr *skg.ParamError
+
 	if err == nil {
 		return C.CString("ok")
+	} else if errors.As(err, &paramErr) {
+		data, _ := json.Marshal(paramErr)
+		return C.CString(string(data))
 	} else {
 		return C.CString(err.Error())
 	}
 }
 
 func jsonToParams(jsonParams *C.char) (skg.FlowParams, error) {
-	var params skg.FlowParams
-
-	if err := json.Unmarshal([]byte(C.GoString(jsonParams)), &params); err != nil {
-		return nil, err
-	}
-
-	return params, nil
+	return skg.DecodeFlowParams([]byte(C.GoString(jsonParams)))
 }
 
 func jsonToMockedKeycard(jsonKeycard *C.char) (*skg.MockedKeycard, error) {
@@ -87,6 +87,65 @@ func KeycardCancelFlow() *C.char {
 	return retErr(err)
 }
 
+//export KeycardResumeFlowID
+func KeycardResumeFlowID(flowID C.ulonglong, jsonParams *C.char) *C.char {
+	params, err := jsonToParams(jsonParams)
+
+	if err != nil {
+		return retErr(err)
+	}
+
+	err = globalFlow.ResumeFlow(uint64(flowID), params)
+	return retErr(err)
+}
+
+//export KeycardCancelFlowID
+func KeycardCancelFlowID(flowID C.ulonglong) *C.char {
+	err := globalFlow.CancelFlow(uint64(flowID))
+	return retErr(err)
+}
+
+//export KeycardEnqueueFlow
+func KeycardEnqueueFlow(requestID *C.char, flowType C.int, jsonParams *C.char) *C.char {
+	params, err := jsonToParams(jsonParams)
+
+	if err != nil {
+		return retErr(err)
+	}
+
+	err = globalFlow.Enqueue(C.GoString(requestID), skg.FlowType(flowType), params)
+	return retErr(err)
+}
+
+//export KeycardCancelRequest
+func KeycardCancelRequest(requestID *C.char) *C.char {
+	err := globalFlow.CancelRequest(C.GoString(requestID))
+	return retErr(err)
+}
+
+//export KeycardStartMonitor
+func KeycardStartMonitor() *C.char {
+	err := globalFlow.StartMonitor()
+	return retErr(err)
+}
+
+//export KeycardStopMonitor
+func KeycardStopMonitor() *C.char {
+	err := globalFlow.StopMonitor()
+	return retErr(err)
+}
+
+//export KeycardGetFlowState
+func KeycardGetFlowState() *C.char {
+	data, err := json.Marshal(globalFlow.GetState())
+
+	if err != nil {
+		return retErr(err)
+	}
+
+	return C.CString(string(data))
+}
+
 //export Free
 func Free(param unsafe.Pointer) {
 	C.free(param)
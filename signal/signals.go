@@ -10,6 +10,7 @@ extern void KeycardSetEventCallback(void *cb);
 import "C"
 import (
 	"encoding/json"
+	"sync"
 	"unsafe"
 
 	"github.com/ethereum/go-ethereum/log"
@@ -19,15 +20,22 @@ import (
 type KeycardSignalHandler func([]byte)
 
 // storing the current signal handler here
-var keycardSignalHandler KeycardSignalHandler
+var (
+	keycardSignalHandler   KeycardSignalHandler
+	keycardSignalHandlerMu sync.RWMutex
+)
 
 // All general log messages in this package should be routed through this logger.
 var logger = log.New("package", "keycard-go/signal")
 
-// Envelope is a general signal sent upward from node to RN app
+// Envelope is a general signal sent upward from node to RN app. Signals of
+// flows carry the ID of the flow and a sequence number, increasing with every
+// signal of the same KeycardFlow.
 type Envelope struct {
-	Type  string      `json:"type"`
-	Event interface{} `json:"event"`
+	Type   string      `json:"type"`
+	Event  interface{} `json:"event"`
+	FlowID uint64      `json:"flowID,omitempty"`
+	Seq    uint64      `json:"seq,omitempty"`
 }
 
 // NewEnvelope creates new envlope of given type and event payload.
@@ -40,15 +48,23 @@ func NewEnvelope(typ string, event interface{}) *Envelope {
 
 // send sends application signal (in JSON) upwards to application (via default notification handler)
 func Send(typ string, event interface{}) {
-	signal := NewEnvelope(typ, event)
-	data, err := json.Marshal(&signal)
+	SendEnvelope(NewEnvelope(typ, event))
+}
+
+// SendEnvelope sends an envelope built by the caller.
+func SendEnvelope(signal *Envelope) {
+	data, err := json.Marshal(signal)
 	if err != nil {
 		logger.Error("Marshalling signal envelope", "error", err)
 		return
 	}
+	keycardSignalHandlerMu.RLock()
+	handler := keycardSignalHandler
+	keycardSignalHandlerMu.RUnlock()
+
 	// If a Go implementation of signal handler is set, let's use it.
-	if keycardSignalHandler != nil {
-		keycardSignalHandler(data)
+	if handler != nil {
+		handler(data)
 	} else {
 		// ...and fallback to C implementation otherwise.
 		str := C.CString(string(data))
@@ -60,6 +76,9 @@ func Send(typ string, event interface{}) {
 // SetKeycardSignalHandler sets new handler for geth events
 // this function uses pure go implementation
 func SetKeycardSignalHandler(handler KeycardSignalHandler) {
+	keycardSignalHandlerMu.Lock()
+	defer keycardSignalHandlerMu.Unlock()
+
 	keycardSignalHandler = handler
 }
 
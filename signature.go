@@ -0,0 +1,42 @@
+package statuskeycardgo
+
+import (
+	"encoding/asn1"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Bytes returns the signature in the [R || S || V] form used by go-ethereum,
+// with V being 0 or 1.
+func (s *Signature) Bytes() []byte {
+	b := make([]byte, 0, crypto.SignatureLength)
+	b = append(b, math.PaddedBigBytes(new(big.Int).SetBytes(s.R), 32)...)
+	b = append(b, math.PaddedBigBytes(new(big.Int).SetBytes(s.S), 32)...)
+
+	return append(b, s.V)
+}
+
+// DERBytes returns the DER encoding of R and S, as used by X.509 and most
+// ECDSA libraries.
+func (s *Signature) DERBytes() ([]byte, error) {
+	return asn1.Marshal(struct{ R, S *big.Int }{new(big.Int).SetBytes(s.R), new(big.Int).SetBytes(s.S)})
+}
+
+// ChainV returns V as in EIP-155 transactions of chainID, or 27 or 28 as in
+// unprotected transactions if chainID is nil.
+func (s *Signature) ChainV(chainID *big.Int) *big.Int {
+	if chainID == nil {
+		return big.NewInt(int64(s.V) + 27)
+	}
+
+	v := new(big.Int).Mul(chainID, big.NewInt(2))
+
+	return v.Add(v, big.NewInt(int64(s.V)+35))
+}
+
+// Recover returns the uncompressed public key which signed hash.
+func (s *Signature) Recover(hash []byte) ([]byte, error) {
+	return crypto.Ecrecover(hash, s.Bytes())
+}
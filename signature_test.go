@@ -0,0 +1,159 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// the signature of the EIP-155 example, by the key 0x4646...46
+const (
+	eip155R      = "0x28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276"
+	eip155S      = "0x67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	eip155Signer = "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"
+)
+
+func eip155Signature() *Signature {
+	return &Signature{R: hexutil.MustDecode(eip155R), S: hexutil.MustDecode(eip155S), V: 0}
+}
+
+func TestSignatureBytes(t *testing.T) {
+	if b := hexutil.Encode(eip155Signature().Bytes()); b != eip155R+strings.TrimPrefix(eip155S, "0x")+"00" {
+		t.Errorf("unexpected encoding %s", b)
+	}
+
+	// R and S are padded to 32 bytes
+	sig := &Signature{R: []byte{0x01}, S: []byte{0x02, 0x03}, V: 1}
+
+	if b := hexutil.Encode(sig.Bytes()); b != "0x"+strings.Repeat("00", 31)+"01"+strings.Repeat("00", 30)+"0203"+"01" {
+		t.Errorf("unexpected encoding %s", b)
+	}
+}
+
+func TestSignatureDERBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		sig      *Signature
+		expected string
+	}{
+		{
+			"EIP-155",
+			eip155Signature(),
+			"0x30440220" + strings.TrimPrefix(eip155R, "0x") + "0220" + strings.TrimPrefix(eip155S, "0x"),
+		},
+		{
+			"high bit and short S",
+			&Signature{R: append([]byte{0x80}, make([]byte, 31)...), S: []byte{0x01}},
+			"0x3026022100" + "80" + strings.Repeat("00", 31) + "020101",
+		},
+		{
+			"leading zeros",
+			&Signature{R: []byte{0x00, 0x00, 0x7f}, S: []byte{0x00, 0x01}},
+			"0x300602017f020101",
+		},
+	}
+
+	for _, test := range tests {
+		der, err := test.sig.DERBytes()
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if hexutil.Encode(der) != test.expected {
+			t.Errorf("%s: expected %s, got %x", test.name, test.expected, der)
+		}
+	}
+}
+
+func TestSignatureChainV(t *testing.T) {
+	tests := []struct {
+		v        byte
+		chainID  *big.Int
+		expected int64
+	}{
+		{0, nil, 27},
+		{1, nil, 28},
+		{0, big.NewInt(1), 37},
+		{1, big.NewInt(1), 38},
+		{1, big.NewInt(5), 46},
+		{0, big.NewInt(1337), 2709},
+	}
+
+	for _, test := range tests {
+		sig := &Signature{V: test.v}
+
+		if v := sig.ChainV(test.chainID); v.Int64() != test.expected {
+			t.Errorf("V %d on chain %v: expected %d, got %s", test.v, test.chainID, test.expected, v)
+		}
+	}
+}
+
+func TestSignatureRecover(t *testing.T) {
+	hash := hexutil.MustDecode(eip155Hash)
+	key, _ := crypto.HexToECDSA(strings.Repeat("46", 32))
+
+	pub, err := eip155Signature().Recover(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hexutil.Encode(pub) != hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey)) {
+		t.Fatalf("unexpected public key %x", pub)
+	}
+
+	if address := common.BytesToAddress(crypto.Keccak256(pub[1:])[12:]); address != common.HexToAddress(eip155Signer) {
+		t.Fatalf("unexpected signer %s", address.Hex())
+	}
+
+	// the other recovery ID gives another key
+	sig := eip155Signature()
+	sig.V = 1
+
+	if pub, err := sig.Recover(hash); err == nil && hexutil.Encode(pub) == hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey)) {
+		t.Fatal("recovered the signer with the wrong recovery ID")
+	}
+
+	sig.V = 4
+
+	if _, err := sig.Recover(hash); err == nil {
+		t.Fatal("recovered with an invalid recovery ID")
+	}
+}
+
+func TestToSignature(t *testing.T) {
+	emu, card := newTestEmulator(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestFlowWithTransport(t, emu.NewTransport)
+	hash := hexutil.MustDecode(eip155Hash)
+	params := FlowParams{PIN: testPIN, BIP44Path: "m/44'/60'/0'/0/0", TXHash: strings.TrimPrefix(eip155Hash, "0x")}
+
+	status, err := f.RunFlow(context.Background(), Sign, params, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, ok := status[TXSignature].(*Signature)
+	if !ok {
+		t.Fatalf("unexpected result %+v", status)
+	}
+
+	der, err := sig.DERBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sig.Address != testAddress || hexutil.Encode(sig.Compact) != hexutil.Encode(sig.Bytes()) || hexutil.Encode(sig.DER) != hexutil.Encode(der) {
+		t.Fatalf("unexpected signature %+v", sig)
+	}
+
+	if pub, err := sig.Recover(hash); err != nil || hexutil.Encode(pub) != hexutil.Encode(sig.PublicKey) {
+		t.Fatalf("recovered %x %v", pub, err)
+	}
+}
@@ -0,0 +1,286 @@
+package statuskeycardgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// TransactionArgs is the JSON form of the unsigned transactions accepted by
+// SignTransaction, as in eth_signTransaction: quantities are hex encoded. The
+// type is guessed from the fee fields if missing. ChainID can be left out if
+// given in the chain-id parameter instead.
+type TransactionArgs struct {
+	Type                 *hexutil.Uint64   `json:"type,omitempty"`
+	ChainID              *hexutil.Big      `json:"chainId,omitempty"`
+	Nonce                *hexutil.Uint64   `json:"nonce"`
+	Gas                  *hexutil.Uint64   `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	To                   *common.Address   `json:"to,omitempty"`
+	Value                *hexutil.Big      `json:"value,omitempty"`
+	Data                 *hexutil.Bytes    `json:"data,omitempty"`
+	Input                *hexutil.Bytes    `json:"input,omitempty"`
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+}
+
+// unsignedLegacyTx also decodes the EIP-155 signing payload, which ends with
+// the chain ID and two zeros, and signed transactions.
+type unsignedLegacyTx struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+	V        *big.Int `rlp:"optional"`
+	R        *big.Int `rlp:"optional"`
+	S        *big.Int `rlp:"optional"`
+}
+
+type unsignedAccessListTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+	V          *big.Int `rlp:"optional"`
+	R          *big.Int `rlp:"optional"`
+	S          *big.Int `rlp:"optional"`
+}
+
+type unsignedDynamicFeeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+	V          *big.Int `rlp:"optional"`
+	R          *big.Int `rlp:"optional"`
+	S          *big.Int `rlp:"optional"`
+}
+
+// parseTransaction decodes the transaction parameter, either TransactionArgs
+// as decoded from JSON or the RLP encoding of the transaction as hex. It
+// returns the transaction along with its chain ID, which defaults to chainID.
+func parseTransaction(v interface{}, chainID *big.Int) (*types.Transaction, *big.Int, error) {
+	switch tx := v.(type) {
+	case string:
+		return decodeTransaction(tx, chainID)
+	case map[string]interface{}:
+		data, err := json.Marshal(tx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var args TransactionArgs
+		if err := json.Unmarshal(data, &args); err != nil {
+			return nil, nil, err
+		}
+
+		return args.toTransaction(chainID)
+	}
+
+	return nil, nil, errors.New("transaction must be an object or a hex string")
+}
+
+func (args *TransactionArgs) toTransaction(chainID *big.Int) (*types.Transaction, *big.Int, error) {
+	if args.Nonce == nil {
+		return nil, nil, errors.New("missing nonce")
+	}
+
+	if args.Gas == nil {
+		return nil, nil, errors.New("missing gas")
+	}
+
+	if args.ChainID != nil {
+		var err error
+		if chainID, err = checkChainID(args.ChainID.ToInt(), chainID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if chainID == nil {
+		return nil, nil, errors.New("missing chain id")
+	}
+
+	var txType uint64
+	switch {
+	case args.Type != nil:
+		txType = uint64(*args.Type)
+	case args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil:
+		txType = types.DynamicFeeTxType
+	case args.AccessList != nil:
+		txType = types.AccessListTxType
+	}
+
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+
+	var data []byte
+	if args.Input != nil {
+		data = *args.Input
+	} else if args.Data != nil {
+		data = *args.Data
+	}
+
+	var accessList types.AccessList
+	if args.AccessList != nil {
+		accessList = *args.AccessList
+	}
+
+	switch txType {
+	case types.LegacyTxType, types.AccessListTxType:
+		if args.GasPrice == nil {
+			return nil, nil, errors.New("missing gasPrice")
+		}
+
+		if txType == types.LegacyTxType {
+			if args.AccessList != nil {
+				return nil, nil, errors.New("legacy transactions have no access list")
+			}
+
+			return types.NewTx(&types.LegacyTx{
+				Nonce:    uint64(*args.Nonce),
+				GasPrice: args.GasPrice.ToInt(),
+				Gas:      uint64(*args.Gas),
+				To:       args.To,
+				Value:    value,
+				Data:     data,
+			}), chainID, nil
+		}
+
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      uint64(*args.Nonce),
+			GasPrice:   args.GasPrice.ToInt(),
+			Gas:        uint64(*args.Gas),
+			To:         args.To,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}), chainID, nil
+	case types.DynamicFeeTxType:
+		if args.MaxFeePerGas == nil || args.MaxPriorityFeePerGas == nil {
+			return nil, nil, errors.New("missing maxFeePerGas or maxPriorityFeePerGas")
+		}
+
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      uint64(*args.Nonce),
+			GasTipCap:  args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap:  args.MaxFeePerGas.ToInt(),
+			Gas:        uint64(*args.Gas),
+			To:         args.To,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}), chainID, nil
+	}
+
+	return nil, nil, fmt.Errorf("unsupported transaction type %d", txType)
+}
+
+func decodeTransaction(str string, chainID *big.Int) (*types.Transaction, *big.Int, error) {
+	raw, err := xtob(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, nil, errors.New("empty transaction")
+	}
+
+	var tx types.TxData
+
+	switch {
+	case raw[0] >= 0xc0:
+		var t unsignedLegacyTx
+		if err := rlp.DecodeBytes(raw, &t); err != nil {
+			return nil, nil, err
+		}
+
+		if txChainID := legacyChainID(&t); txChainID != nil {
+			if chainID, err = checkChainID(txChainID, chainID); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		tx = &types.LegacyTx{Nonce: t.Nonce, GasPrice: t.GasPrice, Gas: t.Gas, To: t.To, Value: t.Value, Data: t.Data}
+	case raw[0] == types.AccessListTxType:
+		var t unsignedAccessListTx
+		if err := rlp.DecodeBytes(raw[1:], &t); err != nil {
+			return nil, nil, err
+		}
+
+		if chainID, err = checkChainID(t.ChainID, chainID); err != nil {
+			return nil, nil, err
+		}
+
+		tx = &types.AccessListTx{ChainID: t.ChainID, Nonce: t.Nonce, GasPrice: t.GasPrice, Gas: t.Gas, To: t.To, Value: t.Value, Data: t.Data, AccessList: t.AccessList}
+	case raw[0] == types.DynamicFeeTxType:
+		var t unsignedDynamicFeeTx
+		if err := rlp.DecodeBytes(raw[1:], &t); err != nil {
+			return nil, nil, err
+		}
+
+		if chainID, err = checkChainID(t.ChainID, chainID); err != nil {
+			return nil, nil, err
+		}
+
+		tx = &types.DynamicFeeTx{ChainID: t.ChainID, Nonce: t.Nonce, GasTipCap: t.GasTipCap, GasFeeCap: t.GasFeeCap, Gas: t.Gas, To: t.To, Value: t.Value, Data: t.Data, AccessList: t.AccessList}
+	default:
+		return nil, nil, fmt.Errorf("unsupported transaction type %d", raw[0])
+	}
+
+	if chainID == nil {
+		return nil, nil, errors.New("missing chain id")
+	}
+
+	return types.NewTx(tx), chainID, nil
+}
+
+// legacyChainID returns the chain ID of the EIP-155 signing payload, or the
+// one of the signature of a protected transaction.
+func legacyChainID(t *unsignedLegacyTx) *big.Int {
+	if t.V == nil || t.V.Sign() == 0 {
+		return nil
+	}
+
+	if (t.R == nil || t.R.Sign() == 0) && (t.S == nil || t.S.Sign() == 0) {
+		return t.V
+	}
+
+	if t.V.Cmp(big.NewInt(35)) < 0 {
+		return nil
+	}
+
+	chainID := new(big.Int).Sub(t.V, big.NewInt(35))
+
+	return chainID.Rsh(chainID, 1)
+}
+
+func checkChainID(txChainID *big.Int, chainID *big.Int) (*big.Int, error) {
+	if chainID != nil && txChainID.Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("chain id %s does not match %s", txChainID, chainID)
+	}
+
+	return txChainID, nil
+}
@@ -0,0 +1,209 @@
+package statuskeycardgo
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// the transaction of the EIP-155 example, as the EIP-155 signing payload, as
+// an unprotected unsigned transaction and signed
+const (
+	eip155Payload  = "0xec098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080018080"
+	eip155Unsigned = "0xe9098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080"
+	eip155Signed   = "0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83"
+	eip155Hash     = "0xdaf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53"
+
+	// the access list transaction of the go-ethereum tests, unsigned and signed
+	eip2930Unsigned = "0x01e00103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c0"
+	eip2930Signed   = "0x01f8630103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c001a0c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b2660a032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d37521"
+	eip2930Hash     = "0x49b486f0ec0a60dfbbca2d30cb07c9e8ffb2a2ff41f29a1ab6737475f6ff69f3"
+
+	// the EIP-155 example as a dynamic fee transaction with 1 and 2 gwei fees,
+	// which is signed as the keccak256 of this encoding
+	eip1559Unsigned = "0x02ef0180843b9aca008477359400825208943535353535353535353535353535353535353535880de0b6b3a764000080c0"
+)
+
+func TestDecodeTransaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		chainID *big.Int
+		txType  uint8
+		hash    string
+	}{
+		{"EIP-155 payload", eip155Payload, nil, types.LegacyTxType, eip155Hash},
+		{"EIP-155 payload with chain id", eip155Payload, big.NewInt(1), types.LegacyTxType, eip155Hash},
+		{"unprotected legacy", eip155Unsigned, big.NewInt(1), types.LegacyTxType, eip155Hash},
+		{"signed legacy", eip155Signed, nil, types.LegacyTxType, eip155Hash},
+		{"EIP-2930", eip2930Unsigned, nil, types.AccessListTxType, eip2930Hash},
+		{"signed EIP-2930", eip2930Signed, nil, types.AccessListTxType, eip2930Hash},
+		{"EIP-1559", eip1559Unsigned, nil, types.DynamicFeeTxType, crypto.Keccak256Hash(hexutil.MustDecode(eip1559Unsigned)).Hex()},
+		{"without 0x", strings.TrimPrefix(eip1559Unsigned, "0x"), nil, types.DynamicFeeTxType, crypto.Keccak256Hash(hexutil.MustDecode(eip1559Unsigned)).Hex()},
+	}
+
+	for _, test := range tests {
+		tx, chainID, err := decodeTransaction(test.raw, test.chainID)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if tx.Type() != test.txType || chainID.Int64() != 1 {
+			t.Errorf("%s: decoded type %d on chain %s", test.name, tx.Type(), chainID)
+		}
+
+		if hash := types.LatestSignerForChainID(chainID).Hash(tx); hash.Hex() != test.hash {
+			t.Errorf("%s: expected signing hash %s, got %s", test.name, test.hash, hash.Hex())
+		}
+	}
+}
+
+func TestDecodeTransactionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		chainID *big.Int
+		err     string
+	}{
+		{"EIP-155 chain mismatch", eip155Payload, big.NewInt(5), "chain id 1 does not match 5"},
+		{"signed chain mismatch", eip155Signed, big.NewInt(5), "chain id 1 does not match 5"},
+		{"EIP-2930 chain mismatch", eip2930Unsigned, big.NewInt(5), "chain id 1 does not match 5"},
+		{"EIP-1559 chain mismatch", eip1559Unsigned, big.NewInt(5), "chain id 1 does not match 5"},
+		{"unprotected without chain id", eip155Unsigned, nil, "missing chain id"},
+		{"empty", "0x", nil, "empty transaction"},
+		{"unknown type", "0x03c0", nil, "unsupported transaction type 3"},
+	}
+
+	for _, test := range tests {
+		if _, _, err := decodeTransaction(test.raw, test.chainID); err == nil || err.Error() != test.err {
+			t.Errorf("%s: expected %q, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestParseTransactionArgs(t *testing.T) {
+	legacy := map[string]interface{}{
+		"nonce":    "0x9",
+		"gasPrice": "0x4a817c800",
+		"gas":      "0x5208",
+		"to":       "0x3535353535353535353535353535353535353535",
+		"value":    "0xde0b6b3a7640000",
+	}
+
+	tx, chainID, err := parseTransaction(legacy, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hash := types.LatestSignerForChainID(chainID).Hash(tx); tx.Type() != types.LegacyTxType || hash.Hex() != eip155Hash {
+		t.Fatalf("unexpected type %d, signing hash %s", tx.Type(), hash.Hex())
+	}
+
+	dynamicFee := map[string]interface{}{
+		"chainId":              "0x1",
+		"nonce":                "0x0",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"maxFeePerGas":         "0x77359400",
+		"gas":                  "0x5208",
+		"to":                   "0x3535353535353535353535353535353535353535",
+		"value":                "0xde0b6b3a7640000",
+	}
+
+	tx, chainID, err = parseTransaction(dynamicFee, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := types.LatestSignerForChainID(chainID).Hash(tx)
+
+	if hash != crypto.Keccak256Hash(hexutil.MustDecode(eip1559Unsigned)) || tx.Type() != types.DynamicFeeTxType || chainID.Int64() != 1 {
+		t.Fatalf("unexpected type %d, signing hash %s on chain %s", tx.Type(), hash.Hex(), chainID)
+	}
+
+	if _, _, err := parseTransaction(dynamicFee, big.NewInt(5)); err == nil || err.Error() != "chain id 1 does not match 5" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, _, err := parseTransaction(legacy, nil); err == nil || err.Error() != "missing chain id" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestSignTransactionFlow(t *testing.T) {
+	emu, card := newTestEmulator(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestFlowWithTransport(t, emu.NewTransport)
+
+	tests := []struct {
+		name   string
+		raw    string
+		txType uint8
+		fields string
+	}{
+		{"legacy", eip155Payload, types.LegacyTxType, "098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080"},
+		{"EIP-2930", eip2930Unsigned, types.AccessListTxType, "0103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c0"},
+		{"EIP-1559", eip1559Unsigned, types.DynamicFeeTxType, "0180843b9aca008477359400825208943535353535353535353535353535353535353535880de0b6b3a764000080c0"},
+	}
+
+	for _, test := range tests {
+		params := FlowParams{PIN: testPIN, BIP44Path: "m/44'/60'/0'/0/0", Transaction: test.raw}
+
+		result, err := f.RunFlow(context.Background(), SignTransaction, params, &refusingPrompter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result[Sender] != testAddress {
+			t.Fatalf("%s: unexpected result %+v", test.name, result)
+		}
+
+		// the signed transaction is encoded with the fields of the unsigned one
+		raw := result[SignedTX].(string)
+
+		if !strings.Contains(raw, test.fields) {
+			t.Errorf("%s: unexpected encoding %s", test.name, raw)
+		}
+
+		if test.txType != types.LegacyTxType && !strings.HasPrefix(raw, fmt.Sprintf("0x%02x", test.txType)) {
+			t.Errorf("%s: unexpected envelope %s", test.name, raw)
+		}
+
+		signed := new(types.Transaction)
+
+		if err := signed.UnmarshalBinary(hexutil.MustDecode(raw)); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if signed.Type() != test.txType || signed.ChainId().Int64() != 1 || signed.Hash().Hex() != result[TXHashOut] {
+			t.Errorf("%s: unexpected transaction %+v", test.name, result)
+		}
+
+		if sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed); err != nil || sender != common.HexToAddress(testAddress) {
+			t.Errorf("%s: recovered %s %v", test.name, sender.Hex(), err)
+		}
+
+		// EIP-155 signatures of chain 1 have a V of 37 or 38
+		if v, _, _ := signed.RawSignatureValues(); test.txType == types.LegacyTxType && v.Int64() != 37 && v.Int64() != 38 {
+			t.Errorf("%s: unexpected V %s", test.name, v)
+		}
+	}
+
+	p := &refusingPrompter{}
+	params := FlowParams{PIN: testPIN, BIP44Path: "m/44'/60'/0'/0/0", Transaction: eip155Payload, ChainID: 5}
+
+	if _, err := f.RunFlow(context.Background(), SignTransaction, params, p); err == nil || len(p.prompted) != 1 || p.prompted[0] != EnterTransaction {
+		t.Fatalf("signed a transaction of another chain, prompted %v", p.prompted)
+	}
+}
@@ -0,0 +1,76 @@
+package statuskeycardgo
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+const (
+	TranscriptListReaders = "list-readers"
+	TranscriptWaitForCard = "wait-for-card"
+	TranscriptConnect     = "connect"
+	TranscriptTransmit    = "transmit"
+)
+
+// TranscriptEntry is a single transport operation captured by the recording
+// transport. Transmit entries hold the APDUs as seen on the wire and, when
+// plaintext recording is enabled, the decrypted secure channel APDUs. The data
+// of commands carrying credentials or seeds is zeroed and Redacted is set.
+type TranscriptEntry struct {
+	Op            string    `json:"op"`
+	Readers       []string  `json:"readers,omitempty"`
+	Reader        string    `json:"reader,omitempty"`
+	Command       hexString `json:"command,omitempty"`
+	Response      hexString `json:"response,omitempty"`
+	PlainCommand  hexString `json:"plainCommand,omitempty"`
+	PlainResponse hexString `json:"plainResponse,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Redacted      bool      `json:"redacted,omitempty"`
+}
+
+// Transcript is a recorded session, stored as one JSON encoded entry per line.
+type Transcript struct {
+	Entries []TranscriptEntry
+}
+
+type transcriptWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// LoadTranscript reads a transcript written by the recording transport.
+func LoadTranscript(r io.Reader) (*Transcript, error) {
+	t := &Transcript{}
+	dec := json.NewDecoder(r)
+
+	for {
+		var e TranscriptEntry
+		err := dec.Decode(&e)
+
+		if err == io.EOF {
+			return t, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		t.Entries = append(t.Entries, e)
+	}
+}
+
+func (w *transcriptWriter) write(e *TranscriptEntry) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.enc.Encode(e); err != nil {
+		l("writing transcript failed %+v", err)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
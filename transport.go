@@ -0,0 +1,87 @@
+package statuskeycardgo
+
+// Transport is the source of readers and card connections used by
+// keycardContext. The PC/SC backend is the default, other backends (emulators,
+// recorded sessions, host provided transports) can be plugged in with
+// NewFlowWithTransport.
+type Transport interface {
+	// ListReaders returns the names of the currently available readers.
+	ListReaders() ([]string, error)
+	// WaitForCard blocks until a card is present in one of the given readers
+	// and returns the index of that reader.
+	WaitForCard(readers []string) (int, error)
+	// WaitForChange blocks until the available readers or the presence of
+	// cards differ from known, and returns the current state. A nil known
+	// state returns immediately.
+	WaitForChange(known []ReaderStatus) ([]ReaderStatus, error)
+	// Connect opens a connection to the card inserted in the given reader.
+	Connect(reader string) (Card, error)
+	// Cancel aborts a pending WaitForCard or WaitForChange.
+	Cancel() error
+	// Release frees all resources held by the transport.
+	Release() error
+}
+
+// Card is a connection to a card, able to exchange raw APDUs.
+type Card interface {
+	Transmit(apdu []byte) ([]byte, error)
+	Disconnect() error
+}
+
+// ReaderStatus is the state of a reader as reported by WaitForChange.
+type ReaderStatus struct {
+	Reader      string
+	CardPresent bool
+}
+
+// TransportFactory establishes a new Transport. It is invoked on the thread
+// which will use the returned Transport until it is released.
+type TransportFactory func() (Transport, error)
+
+// TransportError marks errors of the underlying transport. Flows treat them as
+// connection errors and restart, just like PC/SC errors.
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// pairingKeyListener is implemented by cards which stand in for the secure
+// channel of the real card, like the recording and replay transports, and so
+// need the pairing key used by the flow.
+type pairingKeyListener interface {
+	setPairingKey(key []byte)
+}
+
+// pairingPasswordListener is implemented by cards which also answer the
+// pairing in place of the real card, like the replay transport.
+type pairingPasswordListener interface {
+	setPairingPassword(pairingPassword string)
+}
+
+// wrappingTransport is implemented by transports meant for the connections of
+// a flow only, like the recording transport. Reader watchers use the wrapped
+// transport instead.
+type wrappingTransport interface {
+	unwrap() Transport
+}
+
+func sameReaderStatus(a []ReaderStatus, b []ReaderStatus) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,150 @@
+package statuskeycardgo
+
+import (
+	"time"
+
+	"github.com/ebfe/scard"
+)
+
+// pnpNotification is the pseudo reader whose state changes whenever a reader
+// is added or removed.
+const pnpNotification = `\\?PnP?\Notification`
+
+type pcscTransport struct {
+	ctx *scard.Context
+}
+
+type pcscCard struct {
+	card *scard.Card
+}
+
+// NewPCSCTransport establishes a PC/SC context. It is the default
+// TransportFactory.
+func NewPCSCTransport() (Transport, error) {
+	ctx, err := scard.EstablishContext()
+	if err != nil {
+		return nil, newFlowError(ErrorPCSC, err)
+	}
+
+	return &pcscTransport{ctx: ctx}, nil
+}
+
+func (t *pcscTransport) ListReaders() ([]string, error) {
+	return t.ctx.ListReaders()
+}
+
+func (t *pcscTransport) WaitForCard(readers []string) (int, error) {
+	rs := make([]scard.ReaderState, len(readers))
+
+	for i := range rs {
+		rs[i].Reader = readers[i]
+		rs[i].CurrentState = scard.StateUnaware
+	}
+
+	for {
+		for i := range rs {
+			if rs[i].EventState&scard.StatePresent != 0 {
+				return i, nil
+			}
+
+			rs[i].CurrentState = rs[i].EventState
+		}
+
+		err := t.ctx.GetStatusChange(rs, -1)
+		if err != nil {
+			return -1, err
+		}
+	}
+}
+
+func (t *pcscTransport) WaitForChange(known []ReaderStatus) ([]ReaderStatus, error) {
+	for {
+		readers, err := t.ctx.ListReaders()
+		if err == scard.ErrNoReadersAvailable {
+			readers = []string{}
+		} else if err != nil {
+			return nil, err
+		}
+
+		rs := make([]scard.ReaderState, len(readers), len(readers)+1)
+		current := make([]ReaderStatus, len(readers))
+
+		for i := range rs {
+			rs[i].Reader = readers[i]
+			rs[i].CurrentState = scard.StateUnaware
+		}
+
+		if len(rs) > 0 {
+			err = t.ctx.GetStatusChange(rs, 0)
+			if err != nil && err != scard.ErrTimeout {
+				return nil, err
+			}
+		}
+
+		for i := range rs {
+			current[i] = ReaderStatus{Reader: readers[i], CardPresent: rs[i].EventState&scard.StatePresent != 0}
+			rs[i].CurrentState = rs[i].EventState &^ scard.StateChanged
+		}
+
+		if !sameReaderStatus(known, current) {
+			return current, nil
+		}
+
+		// the high word of the PnP state holds the number of readers on
+		// platforms which count them
+		rs = append(rs, scard.ReaderState{
+			Reader:       pnpNotification,
+			CurrentState: scard.StateFlag(len(readers) << 16),
+		})
+
+		err = t.ctx.GetStatusChange(rs, -1)
+		if err == scard.ErrUnknownReader {
+			// a reader went away before the call, list them again
+			time.Sleep(500 * time.Millisecond)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *pcscTransport) Connect(reader string) (Card, error) {
+	card, err := t.ctx.Connect(reader, scard.ShareShared, scard.ProtocolAny)
+	if err != nil {
+		// error connecting to card
+		time.Sleep(500 * time.Millisecond)
+		return nil, err
+	}
+
+	status, err := card.Status()
+	if err != nil {
+		time.Sleep(500 * time.Millisecond)
+		return nil, err
+	}
+
+	switch status.ActiveProtocol {
+	case scard.ProtocolT0:
+		l("card protocol T0")
+	case scard.ProtocolT1:
+		l("card protocol T1")
+	default:
+		l("card protocol T unknown")
+	}
+
+	return &pcscCard{card: card}, nil
+}
+
+func (t *pcscTransport) Cancel() error {
+	return t.ctx.Cancel()
+}
+
+func (t *pcscTransport) Release() error {
+	return t.ctx.Release()
+}
+
+func (c *pcscCard) Transmit(apdu []byte) ([]byte, error) {
+	return c.card.Transmit(apdu)
+}
+
+func (c *pcscCard) Disconnect() error {
+	return c.card.Disconnect(scard.LeaveCard)
+}
@@ -0,0 +1,346 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	keycard "github.com/status-im/keycard-go"
+	"github.com/status-im/keycard-go/apdu"
+	kcrypto "github.com/status-im/keycard-go/crypto"
+	"github.com/status-im/keycard-go/globalplatform"
+	"github.com/status-im/keycard-go/identifiers"
+	kio "github.com/status-im/keycard-go/io"
+	ktypes "github.com/status-im/keycard-go/types"
+)
+
+var errRecorderNoPairingKey = errors.New("recorder: pairing key unknown")
+
+type recordingTransport struct {
+	transport Transport
+	w         *transcriptWriter
+	plaintext bool
+}
+
+// recordingCard logs every exchanged APDU. In plaintext mode it terminates the
+// secure channel opened by the client, presenting its own key in the SELECT
+// response, and opens a second secure channel to the card so that the
+// decrypted traffic can be logged too.
+type recordingCard struct {
+	card      Card
+	w         *transcriptWriter
+	plaintext bool
+
+	scKey      *ecdsa.PrivateKey
+	pairingKey []byte
+	clientSC   cardSecureChannel
+	cardSC     *keycard.SecureChannel
+}
+
+// NewRecordingTransport wraps a TransportFactory so that every operation on
+// the transport is appended to w as a TranscriptEntry. If plaintext is true the
+// secure channel is decrypted and the plaintext APDUs are logged alongside the
+// encrypted ones. Only plaintext transcripts can be replayed past the opening
+// of the secure channel.
+//
+// The PIN, PUK, pairing and seed data of INIT, PAIR, VERIFY PIN, CHANGE PIN,
+// UNBLOCK PIN and LOAD KEY are redacted, but a plaintext transcript still holds
+// every response in clear, including exported private keys and generated
+// mnemonics. Treat it as secret as the card itself and only record test cards.
+func NewRecordingTransport(inner TransportFactory, w io.Writer, plaintext bool) TransportFactory {
+	tw := &transcriptWriter{enc: json.NewEncoder(w)}
+
+	return func() (Transport, error) {
+		t, err := inner()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &recordingTransport{transport: t, w: tw, plaintext: plaintext}, nil
+	}
+}
+
+func (t *recordingTransport) ListReaders() ([]string, error) {
+	readers, err := t.transport.ListReaders()
+	t.w.write(&TranscriptEntry{Op: TranscriptListReaders, Readers: readers, Error: errString(err)})
+
+	return readers, err
+}
+
+func (t *recordingTransport) WaitForCard(readers []string) (int, error) {
+	index, err := t.transport.WaitForCard(readers)
+	e := &TranscriptEntry{Op: TranscriptWaitForCard, Error: errString(err)}
+
+	if err == nil {
+		e.Reader = readers[index]
+	}
+
+	t.w.write(e)
+
+	return index, err
+}
+
+// unwrap keeps the reader watchers of the flow and of the monitor, and so the
+// connections of the monitor, out of the transcript.
+func (t *recordingTransport) unwrap() Transport {
+	return t.transport
+}
+
+// WaitForChange is not recorded, monitors are not part of a flow session.
+func (t *recordingTransport) WaitForChange(known []ReaderStatus) ([]ReaderStatus, error) {
+	return t.transport.WaitForChange(known)
+}
+
+func (t *recordingTransport) Connect(reader string) (Card, error) {
+	card, err := t.transport.Connect(reader)
+	t.w.write(&TranscriptEntry{Op: TranscriptConnect, Reader: reader, Error: errString(err)})
+
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &recordingCard{card: card, w: t.w, plaintext: t.plaintext}
+
+	if t.plaintext {
+		rc.scKey, err = crypto.GenerateKey()
+
+		if err != nil {
+			_ = card.Disconnect()
+			return nil, err
+		}
+	}
+
+	return rc, nil
+}
+
+func (t *recordingTransport) Cancel() error {
+	return t.transport.Cancel()
+}
+
+func (t *recordingTransport) Release() error {
+	return t.transport.Release()
+}
+
+func (c *recordingCard) setPairingKey(key []byte) {
+	c.pairingKey = key
+}
+
+func (c *recordingCard) Disconnect() error {
+	return c.card.Disconnect()
+}
+
+func (c *recordingCard) Transmit(raw []byte) ([]byte, error) {
+	e := &TranscriptEntry{Op: TranscriptTransmit, Command: raw}
+	resp, err := c.transmit(raw, e)
+
+	e.Response = resp
+	e.Error = errString(err)
+	redact(e)
+	c.w.write(e)
+
+	return resp, err
+}
+
+func (c *recordingCard) transmit(raw []byte, e *TranscriptEntry) ([]byte, error) {
+	if !c.plaintext {
+		return c.card.Transmit(raw)
+	}
+
+	cmd, err := apdu.ParseCommand(raw)
+
+	if err != nil {
+		return c.card.Transmit(raw)
+	}
+
+	if cmd.Cla == globalplatform.ClaISO7816 && cmd.Ins == globalplatform.InsSelect {
+		return c.selectApplet(raw, cmd)
+	}
+
+	if c.cardSC == nil || cmd.Cla != globalplatform.ClaGp {
+		return c.card.Transmit(raw)
+	}
+
+	switch cmd.Ins {
+	case keycard.InsInit:
+		return c.initApplet(cmd, e)
+	case keycard.InsOpenSecureChannel:
+		return c.openSecureChannel(cmd)
+	case keycard.InsPair, keycard.InsFactoryReset:
+		return c.card.Transmit(raw)
+	}
+
+	if !c.clientSC.opened {
+		return c.card.Transmit(raw)
+	}
+
+	plain, ok := c.clientSC.decrypt(cmd)
+
+	if !ok {
+		return swBytes(nil, globalplatform.SwSecurityConditionNotSatisfied), nil
+	}
+
+	plainCmd := apdu.NewCommand(cmd.Cla, cmd.Ins, cmd.P1, cmd.P2, plain)
+	e.PlainCommand, err = plainCmd.Serialize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.cardSC.Send(plainCmd)
+
+	if serr, ok := err.(*apdu.ErrBadResponse); ok {
+		c.clientSC = cardSecureChannel{}
+		return swBytes(nil, serr.Sw), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	e.PlainResponse = swBytes(resp.Data, resp.Sw)
+	enc, err := c.clientSC.encrypt(e.PlainResponse)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return swBytes(enc, apdu.SwOK), nil
+}
+
+func (c *recordingCard) selectApplet(raw []byte, cmd *apdu.Command) ([]byte, error) {
+	c.cardSC = nil
+	c.clientSC = cardSecureChannel{}
+
+	resp, err := c.card.Transmit(raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aid, err := identifiers.KeycardInstanceAID(identifiers.KeycardDefaultInstanceIndex)
+
+	if err != nil || !bytes.Equal(cmd.Data, aid) {
+		return resp, nil
+	}
+
+	r, err := apdu.ParseResponse(resp)
+
+	if err != nil || r.Sw != apdu.SwOK {
+		return resp, nil
+	}
+
+	appInfo, err := ktypes.ParseApplicationInfo(r.Data)
+
+	if err != nil || !appInfo.HasSecureChannelCapability() {
+		return resp, nil
+	}
+
+	c.cardSC = keycard.NewSecureChannel(kio.NewNormalChannel(c.card))
+
+	if err := c.cardSC.GenerateSecret(appInfo.SecureChannelPublicKey); err != nil {
+		c.cardSC = nil
+		return resp, nil
+	}
+
+	return bytes.Replace(resp, appInfo.SecureChannelPublicKey, crypto.FromECDSAPub(&c.scKey.PublicKey), 1), nil
+}
+
+func (c *recordingCard) initApplet(cmd *apdu.Command, e *TranscriptEntry) ([]byte, error) {
+	plain, ok := oneShotDecrypt(c.scKey, cmd.Data)
+
+	if !ok {
+		return swBytes(nil, swWrongData), nil
+	}
+
+	e.PlainCommand = make([]byte, len(plain))
+	e.Redacted = true
+	data, err := kcrypto.OneShotEncrypt(c.cardSC.RawPublicKey(), c.cardSC.Secret(), plain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := keycard.NewCommandInit(data).Serialize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.card.Transmit(raw)
+}
+
+func (c *recordingCard) openSecureChannel(cmd *apdu.Command) ([]byte, error) {
+	c.clientSC = cardSecureChannel{}
+
+	if c.pairingKey == nil {
+		return nil, &TransportError{errRecorderNoPairingKey}
+	}
+
+	clientKey, err := crypto.UnmarshalPubkey(cmd.Data)
+
+	if err != nil {
+		return swBytes(nil, swWrongData), nil
+	}
+
+	raw, err := keycard.NewCommandOpenSecureChannel(cmd.P1, c.cardSC.RawPublicKey()).Serialize()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.card.Transmit(raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := apdu.ParseResponse(resp)
+
+	if err != nil || r.Sw != apdu.SwOK || len(r.Data) != 48 {
+		return resp, nil
+	}
+
+	encKey, macKey, iv := kcrypto.DeriveSessionKeys(c.cardSC.Secret(), c.pairingKey, r.Data)
+	c.cardSC.Init(iv, encKey, macKey)
+	c.clientSC.open(c.scKey, clientKey, c.pairingKey, r.Data)
+
+	return resp, nil
+}
+
+// redact zeroes the secrets of the commands sending credentials or seeds to the
+// card, and the pairing cryptograms, from which the pairing password could be
+// brute forced.
+func redact(e *TranscriptEntry) {
+	if cmd, err := apdu.ParseCommand(e.Command); err == nil && cmd.Cla == globalplatform.ClaGp && cmd.Ins == keycard.InsPair {
+		e.Command = redactedCommand(cmd)
+		e.Redacted = true
+
+		if r, err := apdu.ParseResponse(e.Response); err == nil && r.Sw == apdu.SwOK && len(r.Data) == 64 {
+			e.Response = swBytes(append(make([]byte, 32), r.Data[32:]...), r.Sw)
+		}
+	}
+
+	if e.PlainCommand == nil {
+		return
+	}
+
+	if cmd, err := apdu.ParseCommand(e.PlainCommand); err == nil {
+		switch cmd.Ins {
+		case keycard.InsVerifyPIN, keycard.InsChangePIN, keycard.InsUnblockPIN, keycard.InsLoadKey:
+			e.PlainCommand = redactedCommand(cmd)
+			e.Redacted = true
+		}
+	}
+}
+
+func redactedCommand(cmd *apdu.Command) []byte {
+	raw, err := apdu.NewCommand(cmd.Cla, cmd.Ins, cmd.P1, cmd.P2, make([]byte, len(cmd.Data))).Serialize()
+
+	if err != nil {
+		return nil
+	}
+
+	return raw
+}
@@ -0,0 +1,422 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	keycard "github.com/status-im/keycard-go"
+	"github.com/status-im/keycard-go/apdu"
+	"github.com/status-im/keycard-go/globalplatform"
+	ktypes "github.com/status-im/keycard-go/types"
+)
+
+var errReplayCancelled = errors.New("cancelled")
+
+// Replay feeds a recorded Transcript back to a flow. Commands sent by the flow
+// are checked against the recorded ones and answered with the recorded
+// responses; the secure channel is terminated by the replay itself so that the
+// random values chosen by the client do not break the session. Once the
+// transcript is exhausted, or as soon as the flow diverges from it, the replay
+// behaves like a reader without card. Use its NewTransport method as the
+// TransportFactory of a flow.
+type Replay struct {
+	mu      sync.Mutex
+	entries []TranscriptEntry
+	pos     int
+	err     error
+}
+
+type replayTransport struct {
+	replay *Replay
+
+	mu sync.Mutex
+	// cancel is only set while a wait is pending, see emulatorTransport
+	cancel chan struct{}
+}
+
+type replayCard struct {
+	replay *Replay
+
+	scKey         *ecdsa.PrivateKey
+	pairingSecret []byte
+	pairingKey    []byte
+	sc            cardSecureChannel
+}
+
+// NewReplay creates a replay of the given transcript.
+func NewReplay(t *Transcript) *Replay {
+	return &Replay{entries: t.Entries}
+}
+
+// NewTransport returns a Transport replaying the transcript. It has the
+// signature of a TransportFactory.
+func (r *Replay) NewTransport() (Transport, error) {
+	return &replayTransport{replay: r}, nil
+}
+
+// Err returns the first divergence between the flow and the transcript.
+func (r *Replay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+// Done reports whether all entries of the transcript have been replayed.
+func (r *Replay) Done() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.pos == len(r.entries)
+}
+
+func (r *Replay) next(op string) (*TranscriptEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil || r.pos == len(r.entries) {
+		return nil, false
+	}
+
+	e := &r.entries[r.pos]
+
+	if e.Op != op {
+		r.err = fmt.Errorf("entry %d: expected %s, got %s", r.pos, e.Op, op)
+		return nil, false
+	}
+
+	r.pos++
+
+	return e, true
+}
+
+func (r *Replay) fail(format string, args ...interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil {
+		r.err = fmt.Errorf("entry %d: "+format, append([]interface{}{r.pos - 1}, args...)...)
+	}
+
+	return &TransportError{r.err}
+}
+
+func (r *Replay) ended() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return &TransportError{r.err}
+	}
+
+	return &TransportError{errors.New("transcript ended")}
+}
+
+// readerStatus returns the readers listed in the transcript, with the card in
+// the reader the flow connected to until the replay ends.
+func (r *Replay) readerStatus() []ReaderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var readers []string
+	var cardReader string
+
+	for _, e := range r.entries {
+		switch e.Op {
+		case TranscriptListReaders:
+			if readers == nil {
+				readers = e.Readers
+			}
+		case TranscriptWaitForCard, TranscriptConnect:
+			if cardReader == "" {
+				cardReader = e.Reader
+			}
+		}
+	}
+
+	if readers == nil && cardReader != "" {
+		readers = []string{cardReader}
+	}
+
+	present := r.err == nil && r.pos < len(r.entries)
+	status := make([]ReaderStatus, len(readers))
+
+	for i, reader := range readers {
+		status[i] = ReaderStatus{Reader: reader, CardPresent: present && reader == cardReader}
+	}
+
+	return status
+}
+
+func entryErr(e *TranscriptEntry) error {
+	if e.Error == "" {
+		return nil
+	}
+
+	return &TransportError{errors.New(e.Error)}
+}
+
+func (t *replayTransport) ListReaders() ([]string, error) {
+	e, ok := t.replay.next(TranscriptListReaders)
+
+	if !ok {
+		return nil, t.replay.ended()
+	}
+
+	return e.Readers, entryErr(e)
+}
+
+func (t *replayTransport) WaitForCard(readers []string) (int, error) {
+	e, ok := t.replay.next(TranscriptWaitForCard)
+
+	if !ok {
+		t.wait()
+		return -1, &TransportError{errReplayCancelled}
+	}
+
+	if err := entryErr(e); err != nil {
+		return -1, err
+	}
+
+	for i, reader := range readers {
+		if reader == e.Reader {
+			return i, nil
+		}
+	}
+
+	return -1, t.replay.fail("reader %s not available", e.Reader)
+}
+
+// WaitForChange returns the readers of the transcript if known is nil, then
+// blocks until cancelled, since transcripts do not hold reader changes.
+func (t *replayTransport) WaitForChange(known []ReaderStatus) ([]ReaderStatus, error) {
+	if known == nil {
+		return t.replay.readerStatus(), nil
+	}
+
+	t.wait()
+	return nil, &TransportError{errReplayCancelled}
+}
+
+func (t *replayTransport) Connect(reader string) (Card, error) {
+	e, ok := t.replay.next(TranscriptConnect)
+
+	if !ok {
+		return nil, t.replay.ended()
+	}
+
+	if e.Reader != reader {
+		return nil, t.replay.fail("expected reader %s, got %s", e.Reader, reader)
+	}
+
+	if err := entryErr(e); err != nil {
+		return nil, err
+	}
+
+	scKey, err := crypto.GenerateKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &replayCard{replay: t.replay, scKey: scKey}, nil
+}
+
+func (t *replayTransport) Cancel() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cancel != nil {
+		close(t.cancel)
+		t.cancel = nil
+	}
+
+	return nil
+}
+
+// wait blocks until Cancel is called.
+func (t *replayTransport) wait() {
+	t.mu.Lock()
+	cancel := make(chan struct{})
+	t.cancel = cancel
+	t.mu.Unlock()
+
+	<-cancel
+}
+
+func (t *replayTransport) Release() error {
+	return nil
+}
+
+func (c *replayCard) setPairingPassword(pairingPassword string) {
+	c.pairingSecret = keycard.NewSecrets("", "", pairingPassword).PairingToken()
+}
+
+func (c *replayCard) setPairingKey(key []byte) {
+	c.pairingKey = key
+}
+
+func (c *replayCard) Disconnect() error {
+	return nil
+}
+
+func (c *replayCard) Transmit(raw []byte) ([]byte, error) {
+	e, ok := c.replay.next(TranscriptTransmit)
+
+	if !ok {
+		return nil, c.replay.ended()
+	}
+
+	if err := entryErr(e); err != nil {
+		return nil, err
+	}
+
+	cmd, err := apdu.ParseCommand(raw)
+
+	if err != nil {
+		return nil, c.replay.fail("unparsable command %x", raw)
+	}
+
+	recorded, err := apdu.ParseCommand(e.Command)
+
+	if err != nil || cmd.Cla != recorded.Cla || cmd.Ins != recorded.Ins || cmd.P1 != recorded.P1 || cmd.P2 != recorded.P2 {
+		return nil, c.replay.fail("expected command %x, got %x", []byte(e.Command), raw)
+	}
+
+	if cmd.Cla == globalplatform.ClaISO7816 && cmd.Ins == globalplatform.InsSelect {
+		return c.selectApplet(raw, e)
+	}
+
+	if cmd.Cla == globalplatform.ClaGp {
+		switch cmd.Ins {
+		case keycard.InsInit:
+			return c.initApplet(cmd, e)
+		case keycard.InsPair:
+			return c.pair(cmd, e)
+		case keycard.InsOpenSecureChannel:
+			return c.openSecureChannel(cmd, e)
+		}
+
+		if c.sc.opened {
+			return c.secureTransmit(cmd, e)
+		}
+	}
+
+	if !bytes.Equal(raw, e.Command) {
+		return nil, c.replay.fail("expected command %x, got %x", []byte(e.Command), raw)
+	}
+
+	return e.Response, nil
+}
+
+func (c *replayCard) selectApplet(raw []byte, e *TranscriptEntry) ([]byte, error) {
+	c.sc = cardSecureChannel{}
+
+	if !bytes.Equal(raw, e.Command) {
+		return nil, c.replay.fail("expected command %x, got %x", []byte(e.Command), raw)
+	}
+
+	r, err := apdu.ParseResponse(e.Response)
+
+	if err != nil || r.Sw != apdu.SwOK || len(r.Data) == 0 {
+		return e.Response, nil
+	}
+
+	appInfo, err := ktypes.ParseApplicationInfo(r.Data)
+
+	if err != nil || len(appInfo.SecureChannelPublicKey) == 0 {
+		return e.Response, nil
+	}
+
+	return bytes.Replace(e.Response, appInfo.SecureChannelPublicKey, crypto.FromECDSAPub(&c.scKey.PublicKey), 1), nil
+}
+
+func (c *replayCard) initApplet(cmd *apdu.Command, e *TranscriptEntry) ([]byte, error) {
+	plain, ok := oneShotDecrypt(c.scKey, cmd.Data)
+
+	if !ok || (e.PlainCommand != nil && !e.Redacted && !bytes.Equal(plain, e.PlainCommand)) {
+		return nil, c.replay.fail("INIT parameters differ from the transcript")
+	}
+
+	return e.Response, nil
+}
+
+func (c *replayCard) pair(cmd *apdu.Command, e *TranscriptEntry) ([]byte, error) {
+	r, err := apdu.ParseResponse(e.Response)
+
+	if err != nil || r.Sw != apdu.SwOK || cmd.P1 != keycard.P1PairingFirstStep {
+		return e.Response, nil
+	}
+
+	if c.pairingSecret == nil || len(r.Data) != 64 {
+		return nil, c.replay.fail("cannot replay pairing")
+	}
+
+	cryptogram := sha256.Sum256(append(append([]byte{}, c.pairingSecret...), cmd.Data...))
+
+	return swBytes(append(cryptogram[:], r.Data[32:]...), apdu.SwOK), nil
+}
+
+func (c *replayCard) openSecureChannel(cmd *apdu.Command, e *TranscriptEntry) ([]byte, error) {
+	c.sc = cardSecureChannel{}
+
+	r, err := apdu.ParseResponse(e.Response)
+
+	if err != nil || r.Sw != apdu.SwOK || len(r.Data) != 48 {
+		return e.Response, nil
+	}
+
+	clientKey, err := crypto.UnmarshalPubkey(cmd.Data)
+
+	if err != nil || c.pairingKey == nil {
+		return nil, c.replay.fail("cannot replay secure channel opening")
+	}
+
+	c.sc.open(c.scKey, clientKey, c.pairingKey, r.Data)
+
+	return e.Response, nil
+}
+
+func (c *replayCard) secureTransmit(cmd *apdu.Command, e *TranscriptEntry) ([]byte, error) {
+	if e.PlainCommand == nil {
+		r, err := apdu.ParseResponse(e.Response)
+
+		if err == nil && r.Sw != apdu.SwOK {
+			c.sc = cardSecureChannel{}
+			return e.Response, nil
+		}
+
+		return nil, c.replay.fail("transcript was recorded without plaintext")
+	}
+
+	plain, ok := c.sc.decrypt(cmd)
+
+	if !ok {
+		return nil, c.replay.fail("secure channel error")
+	}
+
+	recorded, err := apdu.ParseCommand(e.PlainCommand)
+
+	// the mutual authentication challenge is random and redacted data is
+	// unknown, only their length can be checked
+	if err != nil || len(plain) != len(recorded.Data) {
+		return nil, c.replay.fail("expected plaintext %x, got %x", []byte(e.PlainCommand), plain)
+	}
+
+	if cmd.Ins != keycard.InsMutuallyAuthenticate && !e.Redacted && !bytes.Equal(plain, recorded.Data) {
+		return nil, c.replay.fail("expected plaintext %x, got %x", []byte(e.PlainCommand), plain)
+	}
+
+	enc, err := c.sc.encrypt(e.PlainResponse)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return swBytes(enc, apdu.SwOK), nil
+}
@@ -0,0 +1,133 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordSign runs a Sign flow on an emulated card with a key, recording a
+// plaintext transcript.
+func recordSign(t *testing.T) (FlowStatus, []byte) {
+	t.Helper()
+
+	emu, card := newTestEmulator(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	f := newTestFlowWithTransport(t, NewRecordingTransport(emu.NewTransport, &buf, true))
+
+	result, err := f.RunFlow(context.Background(), Sign, signParams(testPIN), &refusingPrompter{})
+	if err != nil || result[TXSignature] == nil {
+		t.Fatalf("sign failed: %+v %v", result, err)
+	}
+
+	return result, buf.Bytes()
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	recorded, transcript := recordSign(t)
+
+	tr, err := LoadTranscript(bytes.NewReader(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay := NewReplay(tr)
+	f := newTestFlowWithTransport(t, replay.NewTransport)
+
+	replayed, err := f.RunFlow(context.Background(), Sign, signParams(testPIN), &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replay.Err(); err != nil || !replay.Done() {
+		t.Fatalf("replay incomplete: %v", err)
+	}
+
+	a, _ := json.Marshal(recorded)
+	b, _ := json.Marshal(replayed)
+
+	if !bytes.Equal(a, b) {
+		t.Fatalf("recorded %s, replayed %s", a, b)
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	_, transcript := recordSign(t)
+
+	tr, err := LoadTranscript(bytes.NewReader(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay := NewReplay(tr)
+	f := newTestFlowWithTransport(t, replay.NewTransport)
+
+	params := signParams(testPIN)
+	params[BIP44Path] = "m/44'/60'/0'/0/1"
+
+	result, err := f.RunFlow(context.Background(), Sign, params, &refusingPrompter{})
+	if err == nil && result[TXSignature] != nil {
+		t.Fatalf("replayed a different flow: %+v", result)
+	}
+
+	if replay.Err() == nil {
+		t.Fatal("divergence not reported")
+	}
+}
+
+func TestRecordingRedactsSecrets(t *testing.T) {
+	_, transcript := recordSign(t)
+
+	if pin := hex.EncodeToString([]byte(testPIN)); strings.Contains(string(transcript), pin) {
+		t.Fatalf("transcript contains the PIN: %s", transcript)
+	}
+
+	tr, err := LoadTranscript(bytes.NewReader(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redacted := 0
+
+	for _, e := range tr.Entries {
+		if e.Redacted {
+			redacted++
+		}
+	}
+
+	// both pairing steps and VERIFY PIN
+	if redacted != 3 {
+		t.Fatalf("%d redacted entries", redacted)
+	}
+}
+
+func TestReplayReaderStatus(t *testing.T) {
+	_, transcript := recordSign(t)
+
+	tr, err := LoadTranscript(bytes.NewReader(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport, _ := NewReplay(tr).NewTransport()
+
+	status, err := transport.WaitForChange(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ReaderStatus{{Reader: testReader, CardPresent: true}}
+
+	if !reflect.DeepEqual(status, expected) {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
@@ -0,0 +1,181 @@
+package statuskeycardgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+const eip712Domain = "EIP712Domain"
+
+// typedDataHashes are the hashes of EIP-712 typed data: the domain separator,
+// the struct hash of the message and the hash signed, which combines both.
+type typedDataHashes struct {
+	domain  []byte
+	message []byte
+	hash    []byte
+}
+
+// parseTypedData decodes the typed-data parameter, the eth_signTypedData_v4
+// payload either as decoded from JSON or as JSON string. Objects must have
+// been decoded with UseNumber, like DecodeFlowParams does, if they hold
+// integers beyond 2^53.
+func parseTypedData(v interface{}) (*apitypes.TypedData, error) {
+	var raw map[string]interface{}
+
+	switch td := v.(type) {
+	case string:
+		d := json.NewDecoder(strings.NewReader(td))
+		d.UseNumber()
+
+		if err := d.Decode(&raw); err != nil {
+			return nil, err
+		}
+	case map[string]interface{}:
+		raw = td
+	default:
+		return nil, errors.New("typed data must be an object or a JSON string")
+	}
+
+	exact, err := exactNumbers(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(withStringChainID(exact.(map[string]interface{})))
+	if err != nil {
+		return nil, err
+	}
+
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		return nil, err
+	}
+
+	if _, ok := typedData.Types[eip712Domain]; !ok {
+		return nil, fmt.Errorf("missing %s type", eip712Domain)
+	}
+
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return nil, fmt.Errorf("primary type %q is not defined", typedData.PrimaryType)
+	}
+
+	if len(typedData.Domain.Map()) == 0 {
+		return nil, errors.New("domain is undefined")
+	}
+
+	return &typedData, nil
+}
+
+// exactNumbers returns a copy of v with the json.Number values as strings,
+// which go-ethereum parses as integers without going through float64, so
+// that uint256 values keep their precision. float64 values too large to be
+// exact integers are rejected: they were decoded from JSON without UseNumber
+// and might have lost precision already. DecodeFlowParams keeps them exact.
+func exactNumbers(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return t.String(), nil
+	case float64:
+		if math.Abs(t) > 1<<53 {
+			return nil, fmt.Errorf("number %g is not exact, pass it as string or json.Number", t)
+		}
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(t))
+
+		for k, item := range t {
+			n, err := exactNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+
+			c[k] = n
+		}
+
+		return c, nil
+	case []interface{}:
+		c := make([]interface{}, len(t))
+
+		for i, item := range t {
+			n, err := exactNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+
+			c[i] = n
+		}
+
+		return c, nil
+	}
+
+	return v, nil
+}
+
+// withStringChainID returns a copy of the typed data with the chain ID of the
+// domain as string. Wallets often send numbers, which go-ethereum rejects.
+func withStringChainID(raw map[string]interface{}) map[string]interface{} {
+	domain, ok := raw["domain"].(map[string]interface{})
+	if !ok {
+		return raw
+	}
+
+	id, ok := domain["chainId"].(float64)
+	if !ok {
+		return raw
+	}
+
+	chainID := strconv.FormatFloat(id, 'f', -1, 64)
+
+	c := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		c[k] = v
+	}
+
+	d := make(map[string]interface{}, len(domain))
+	for k, v := range domain {
+		d[k] = v
+	}
+
+	d["chainId"] = chainID
+	c["domain"] = d
+
+	return c
+}
+
+func hashTypedData(typedData *apitypes.TypedData) (*typedDataHashes, error) {
+	domain, err := typedData.HashStruct(eip712Domain, typedData.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := crypto.Keccak256([]byte{0x19, 0x01}, domain, message)
+
+	return &typedDataHashes{domain: domain, message: message, hash: hash}, nil
+}
+
+// textHash is the hash signed by personal_sign, as defined by EIP-191.
+func textHash(message []byte) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return crypto.Keccak256([]byte(prefix), message)
+}
+
+// ethSignature encodes the signature as returned by eth_sign and
+// eth_signTypedData: [R || S || V] in hex, with V being 27 or 28.
+func ethSignature(sig *Signature) string {
+	b := sig.Bytes()
+	b[crypto.RecoveryIDOffset] += 27
+
+	return hexutil.Encode(b)
+}
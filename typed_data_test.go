@@ -0,0 +1,276 @@
+package statuskeycardgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	keycard "github.com/status-im/keycard-go"
+)
+
+// the Mail example of EIP-712, with its domain separator, the struct hash of
+// its message and the hash signed
+const (
+	mailTypedData = `{
+		"types": {
+			"EIP712Domain": [
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"}
+			],
+			"Person": [
+				{"name": "name", "type": "string"},
+				{"name": "wallet", "type": "address"}
+			],
+			"Mail": [
+				{"name": "from", "type": "Person"},
+				{"name": "to", "type": "Person"},
+				{"name": "contents", "type": "string"}
+			]
+		},
+		"primaryType": "Mail",
+		"domain": {
+			"name": "Ether Mail",
+			"version": "1",
+			"chainId": 1,
+			"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+		},
+		"message": {
+			"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+			"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+			"contents": "Hello, Bob!"
+		}
+	}`
+
+	mailDomainHash  = "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"
+	mailMessageHash = "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"
+	mailHash        = "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"
+)
+
+// the typed data of an order, with an amount beyond the precision of float64
+const orderTypedData = `{
+	"types": {
+		"EIP712Domain": [{"name": "chainId", "type": "uint256"}],
+		"Order": [{"name": "amount", "type": "uint256"}]
+	},
+	"primaryType": "Order",
+	"domain": {"chainId": 1},
+	"message": {"amount": 18446744073709551617}
+}`
+
+func hashTypedDataParam(t *testing.T, v interface{}) *typedDataHashes {
+	t.Helper()
+
+	td, err := parseTypedData(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashes, err := hashTypedData(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return hashes
+}
+
+// decodedTypedData decodes typed data like the C API does.
+func decodedTypedData(t *testing.T, typedData string) interface{} {
+	t.Helper()
+
+	params, err := DecodeFlowParams([]byte(`{"typed-data": ` + typedData + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return params[TypedData]
+}
+
+func TestHashTypedData(t *testing.T) {
+	var plain map[string]interface{}
+
+	if err := json.Unmarshal([]byte(mailTypedData), &plain); err != nil {
+		t.Fatal(err)
+	}
+
+	forms := map[string]interface{}{
+		"string":  mailTypedData,
+		"decoded": decodedTypedData(t, mailTypedData),
+		"float64": plain,
+	}
+
+	for name, v := range forms {
+		hashes := hashTypedDataParam(t, v)
+
+		if hexutil.Encode(hashes.domain) != mailDomainHash {
+			t.Errorf("%s: unexpected domain separator %x", name, hashes.domain)
+		}
+
+		if hexutil.Encode(hashes.message) != mailMessageHash {
+			t.Errorf("%s: unexpected struct hash %x", name, hashes.message)
+		}
+
+		if hexutil.Encode(hashes.hash) != mailHash {
+			t.Errorf("%s: unexpected hash %x", name, hashes.hash)
+		}
+	}
+}
+
+func TestHashTypedDataPrecision(t *testing.T) {
+	expected := hashTypedDataParam(t, strings.Replace(orderTypedData, "18446744073709551617", `"18446744073709551617"`, 1))
+
+	if hashes := hashTypedDataParam(t, orderTypedData); hexutil.Encode(hashes.hash) != hexutil.Encode(expected.hash) {
+		t.Errorf("string: unexpected hash %x", hashes.hash)
+	}
+
+	if hashes := hashTypedDataParam(t, decodedTypedData(t, orderTypedData)); hexutil.Encode(hashes.hash) != hexutil.Encode(expected.hash) {
+		t.Errorf("decoded: unexpected hash %x", hashes.hash)
+	}
+
+	var plain map[string]interface{}
+
+	if err := json.Unmarshal([]byte(orderTypedData), &plain); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseTypedData(plain); err == nil {
+		t.Error("parsed an amount which lost its precision")
+	}
+}
+
+func TestSignTypedDataFlow(t *testing.T) {
+	emu, card := newTestEmulator(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestFlowWithTransport(t, emu.NewTransport)
+	params := FlowParams{PIN: testPIN, BIP44Path: "m/44'/60'/0'/0/0", TypedData: decodedTypedData(t, mailTypedData)}
+
+	result, err := f.RunFlow(context.Background(), SignTypedData, params, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result[DomainHash] != mailDomainHash || result[MessageHash] != mailMessageHash || result[SignedHash] != mailHash {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// eth_signTypedData signatures have a V of 27 or 28
+	sig := hexutil.MustDecode(result[RawSignature].(string))
+
+	if len(sig) != 65 || sig[64] != 27 && sig[64] != 28 {
+		t.Fatalf("unexpected signature %x", sig)
+	}
+
+	sig[64] -= 27
+
+	pub, err := crypto.SigToPub(hexutil.MustDecode(mailHash), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if crypto.PubkeyToAddress(*pub) != common.HexToAddress(testAddress) {
+		t.Fatalf("signed by %s", crypto.PubkeyToAddress(*pub).Hex())
+	}
+}
+
+func TestTextHash(t *testing.T) {
+	// the hashMessage("Hello World") example of ethers
+	if hash := hexutil.Encode(textHash([]byte("Hello World"))); hash != "0xa1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2" {
+		t.Fatalf("unexpected hash %s", hash)
+	}
+}
+
+func TestEthSignature(t *testing.T) {
+	r := bytes.Repeat([]byte{0x11}, 32)
+	s := bytes.Repeat([]byte{0x22}, 32)
+
+	for v, expected := range []byte{27, 28} {
+		sig := &Signature{R: r, S: s, V: byte(v)}
+		raw := hexutil.MustDecode(ethSignature(sig))
+
+		if !bytes.Equal(raw[:32], r) || !bytes.Equal(raw[32:64], s) || raw[64] != expected {
+			t.Errorf("V %d encoded as %x", v, raw)
+		}
+
+		// the signature itself keeps the recovery ID
+		if sig.V != byte(v) {
+			t.Errorf("V %d changed to %d", v, sig.V)
+		}
+	}
+}
+
+// exportCountingTransport counts the EXPORT KEY commands sent to the card.
+type exportCountingTransport struct {
+	Transport
+	exports *int32
+}
+
+type exportCountingCard struct {
+	Card
+	exports *int32
+}
+
+func (t *exportCountingTransport) Connect(reader string) (Card, error) {
+	card, err := t.Transport.Connect(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exportCountingCard{card, t.exports}, nil
+}
+
+func (c *exportCountingCard) Transmit(apdu []byte) ([]byte, error) {
+	if len(apdu) > 1 && apdu[1] == keycard.InsExportKey {
+		atomic.AddInt32(c.exports, 1)
+	}
+
+	return c.Card.Transmit(apdu)
+}
+
+func TestSignMessageFlow(t *testing.T) {
+	emu, card := newTestEmulator(t)
+
+	if err := card.LoadMnemonic(testMnemonic, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var exports int32
+
+	f := newTestFlowWithTransport(t, func() (Transport, error) {
+		inner, err := emu.NewTransport()
+		if err != nil {
+			return nil, err
+		}
+
+		return &exportCountingTransport{inner, &exports}, nil
+	})
+
+	params := FlowParams{PIN: testPIN, BIP44Path: "m/44'/60'/0'/0/0", Message: "Hello World"}
+
+	result, err := f.RunFlow(context.Background(), SignMessage, params, &refusingPrompter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result[Address] != testAddress || result[SignedHash] != "0xa1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	if sig := hexutil.MustDecode(result[RawSignature].(string)); sig[64] != 27 && sig[64] != 28 {
+		t.Fatalf("unexpected signature %x", sig)
+	}
+
+	if n := atomic.LoadInt32(&exports); n != 0 {
+		t.Fatalf("exported %d keys to check the signer", n)
+	}
+}
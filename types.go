@@ -2,6 +2,7 @@ package statuskeycardgo
 
 import (
 	"encoding/json"
+	"math/big"
 )
 
 type hexString []byte
@@ -28,10 +29,27 @@ func (s *hexString) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Signature is an ECDSA signature of the card, V being the recovery ID. The
+// other fields are derived from it: the public key and address of the signer,
+// recovered from the signed hash, the [R || S || V] and DER encodings and, if
+// the chain ID is known, the V of EIP-155 transactions.
 type Signature struct {
-	R hexString `json:"r"`
-	S hexString `json:"s"`
-	V byte      `json:"v"`
+	R         hexString `json:"r"`
+	S         hexString `json:"s"`
+	V         byte      `json:"v"`
+	PublicKey hexString `json:"publicKey,omitempty"`
+	Address   string    `json:"address,omitempty"`
+	Compact   hexString `json:"compact,omitempty"`
+	DER       hexString `json:"der,omitempty"`
+	EIP155V   *big.Int  `json:"eip155V,omitempty"`
+}
+
+// BatchSignature is the outcome of an item of SignBatch: its signature, or why
+// it could not be signed, under the keys of the SignProgress signal of the
+// item.
+type BatchSignature struct {
+	Signature *Signature `json:"tx-signature,omitempty"`
+	Error     *FlowError `json:"error-info,omitempty"`
 }
 
 type ApplicationInfo struct {
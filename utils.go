@@ -1,17 +1,28 @@
 package statuskeycardgo
 
 import (
+	"crypto/sha512"
 	"encoding/binary"
 	"encoding/hex"
 
 	"github.com/ebfe/scard"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	keycard "github.com/status-im/keycard-go"
 	"github.com/status-im/keycard-go/derivationpath"
 	ktypes "github.com/status-im/keycard-go/types"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
 )
 
+const bip39Salt = "mnemonic"
+
 func isSCardError(err error) bool {
-	_, ok := err.(scard.Error)
+	if _, ok := err.(scard.Error); ok {
+		return true
+	}
+
+	_, ok := err.(*TransportError)
 	return ok
 }
 
@@ -33,6 +44,10 @@ func xtob(str string) ([]byte, error) {
 	return hex.DecodeString(str)
 }
 
+func mnemonicToSeed(mnemonic string, password string) []byte {
+	return pbkdf2.Key(norm.NFKD.Bytes([]byte(mnemonic)), norm.NFKD.Bytes([]byte(bip39Salt+password)), 2048, 64, sha512.New)
+}
+
 func bytesToInt(s []byte) int {
 	if len(s) > 4 {
 		return 0
@@ -60,12 +75,36 @@ func toPairInfo(r *ktypes.PairingInfo) *PairingInfo {
 	}
 }
 
-func toSignature(r *ktypes.Signature) *Signature {
-	return &Signature{
+// toSignature converts the signature of hash returned by the card, recovering
+// the signer with ecrecover.
+func toSignature(hash []byte, r *ktypes.Signature) *Signature {
+	sig := &Signature{
 		R: r.R(),
 		S: r.S(),
 		V: r.V(),
 	}
+
+	sig.Compact = sig.Bytes()
+
+	der, err := sig.DERBytes()
+
+	if err != nil {
+		l("encoding the signature as DER failed %+v", err)
+	} else {
+		sig.DER = der
+	}
+
+	pubKey, err := sig.Recover(hash)
+
+	if err != nil {
+		l("recovering the signer failed %+v", err)
+		return sig
+	}
+
+	sig.PublicKey = pubKey
+	sig.Address = common.BytesToAddress(crypto.Keccak256(pubKey[1:])[12:]).Hex()
+
+	return sig
 }
 
 func toMetadata(r *ktypes.Metadata) *Metadata {